@@ -0,0 +1,281 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+	"traitor/config"
+	"traitor/dao/model"
+	"traitor/logger"
+)
+
+const sendTimeout = 10 * time.Second
+
+// Message what a notification tells, it's the data of the templates.
+type Message struct {
+	Rule      string    `json:"rule"`
+	Trigger   string    `json:"trigger"`
+	Title     string    `json:"title"`
+	Namespace string    `json:"namespace"`
+	JobId     string    `json:"jobId"`
+	JobName   string    `json:"jobName"`
+	RunId     string    `json:"runId"`
+	Error     string    `json:"error,omitempty"`
+	Failures  int       `json:"failures,omitempty"` // failures in a row.
+	StartAt   time.Time `json:"startAt"`
+	Link      string    `json:"link"`
+}
+
+const defaultTemplate = `[traitor] {{.Title}}
+job: {{.JobName}} ({{.JobId}})
+run: {{.RunId}}, started at {{.StartAt.Format "2006-01-02 15:04:05"}}
+{{- if .Error}}
+error: {{.Error}}
+{{- end}}
+{{.Link}}`
+
+// Sender deliver the rendered text through a type of channel.
+type Sender interface {
+	Check(ch *model.ChannelEntity) error
+	Send(ctx context.Context, ch *model.ChannelEntity, msg *Message, text string) error
+}
+
+var senders = map[string]Sender{
+	model.WebhookChannel:  webhook{},
+	model.SlackChannel:    slack{},
+	model.DingTalkChannel: dingTalk{},
+	model.FeishuChannel:   feishu{},
+	model.EmailChannel:    email{},
+}
+
+// Register a sender for a type of channel, it's called during init.
+func Register(typ string, s Sender) {
+	senders[typ] = s
+}
+
+// CheckChannel verify the settings of a channel.
+func CheckChannel(ch *model.ChannelEntity) error {
+	s, ok := senders[ch.Type]
+	if ok == false {
+		return fmt.Errorf("unknown channel type: %s", ch.Type)
+	}
+	if ch.Name == "" {
+		return errors.New("channel name cannot be empty")
+	}
+	if ch.Template != "" {
+		if _, err := template.New("").Parse(ch.Template); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+	}
+	return s.Check(ch)
+}
+
+// CheckRule verify the settings of a rule, the channels are not looked up.
+func CheckRule(rule *model.AlertRuleEntity) error {
+	if rule.Name == "" {
+		return errors.New("rule name cannot be empty")
+	}
+	switch rule.Trigger {
+	case model.TriggerFailure, model.TriggerRecovery:
+	case model.TriggerConsecutive:
+		if rule.Threshold < 2 {
+			return errors.New("threshold of consecutive failures should be at least 2")
+		}
+	case model.TriggerTimeout:
+		if rule.Timeout <= 0 {
+			return errors.New("timeout should be positive")
+		}
+	default:
+		return fmt.Errorf("unknown trigger: %s", rule.Trigger)
+	}
+	if len(rule.Channels) == 0 {
+		return errors.New("channels cannot be empty")
+	}
+	return nil
+}
+
+// Send render the message with the template of the channel and deliver it.
+func Send(ctx context.Context, ch *model.ChannelEntity, msg *Message) error {
+	s, ok := senders[ch.Type]
+	if ok == false {
+		return fmt.Errorf("unknown channel type: %s", ch.Type)
+	}
+	text, err := render(ch, msg)
+	if err != nil {
+		return err
+	}
+	return s.Send(ctx, ch, msg, text)
+}
+
+func render(ch *model.ChannelEntity, msg *Message) (string, error) {
+	src := ch.Template
+	if src == "" {
+		src = defaultTemplate
+	}
+	tpl, err := template.New(ch.ChannelId).Parse(src)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	err = tpl.Execute(&sb, msg)
+	return sb.String(), err
+}
+
+// runLink where the record of the run could be viewed.
+func runLink(ns string, runId string) string {
+	link, err := url.JoinPath(config.GetConfig(config.PublicUrl), "/api/runs", runId)
+	if err != nil {
+		link = "/api/runs/" + runId
+	}
+	if ns != "" && ns != model.DefaultNamespace {
+		link += "?namespace=" + url.QueryEscape(ns)
+	}
+	return link
+}
+
+// Store where the rules, channels and runs are loaded, it's implemented by dao.Dao.
+type Store interface {
+	GetAlertRules() ([]model.AlertRuleEntity, error)
+	GetChannel(channelId string) (model.ChannelEntity, error)
+	GetRuns(jobId string) ([]model.RunEntity, error)
+}
+
+// Alerter evaluate the rules around the runs of the jobs.
+type Alerter struct {
+	store Store
+}
+
+func New(store Store) *Alerter {
+	return &Alerter{store: store}
+}
+
+func (a *Alerter) rules(j *model.JobEntity) []model.AlertRuleEntity {
+	all, err := a.store.GetAlertRules()
+	if err != nil {
+		logger.Error("load alert rules error:", err)
+		return nil
+	}
+	res := make([]model.AlertRuleEntity, 0)
+	for _, rule := range all {
+		if rule.Disabled == false && rule.Watches(j) {
+			res = append(res, rule)
+		}
+	}
+	return res
+}
+
+// RunStarted arm the timeout rules of the run, call the returned func once the run finished.
+func (a *Alerter) RunStarted(j *model.JobEntity, run *model.RunEntity) func() {
+	timers := make([]*time.Timer, 0)
+	for _, rule := range a.rules(j) {
+		if rule.Trigger != model.TriggerTimeout {
+			continue
+		}
+		rule := rule
+		msg := newMessage(&rule, j, run)
+		msg.Title = fmt.Sprintf("job is still running after %ds", rule.Timeout)
+		timers = append(timers, time.AfterFunc(time.Duration(rule.Timeout)*time.Second, func() {
+			a.notify(&rule, msg)
+		}))
+	}
+	return func() {
+		for _, t := range timers {
+			t.Stop()
+		}
+	}
+}
+
+// RunFinished notify the rules triggered by the run, the record of the run should be saved already.
+// the channels are notified async.
+func (a *Alerter) RunFinished(j *model.JobEntity, run *model.RunEntity) {
+	if run.State != model.RunSuccess && run.State != model.RunFailed {
+		return // cancelled.
+	}
+	rules := a.rules(j)
+	if len(rules) == 0 {
+		return
+	}
+	failures, previous := a.history(run)
+	for _, rule := range rules {
+		rule := rule
+		msg := newMessage(&rule, j, run)
+		msg.Failures = failures
+		switch {
+		case rule.Trigger == model.TriggerFailure && run.State == model.RunFailed:
+			msg.Title = "job failed"
+		case rule.Trigger == model.TriggerConsecutive && failures == rule.Threshold:
+			msg.Title = fmt.Sprintf("job failed %d times in a row", failures)
+		case rule.Trigger == model.TriggerRecovery && run.State == model.RunSuccess && previous == model.RunFailed:
+			msg.Title = "job recovered"
+		default:
+			continue
+		}
+		go a.notify(&rule, msg)
+	}
+}
+
+// history the failures in a row ending with the run and the state of the run before it.
+// runs in flight or cancelled are skipped.
+func (a *Alerter) history(run *model.RunEntity) (failures int, previous uint8) {
+	previous = model.RunRunning
+	runs, err := a.store.GetRuns(run.JobId)
+	if err != nil {
+		logger.Error("load runs error:", err)
+		return 0, previous
+	}
+	if run.State == model.RunFailed {
+		failures = 1
+	}
+	counting := failures == 1
+	for _, r := range runs {
+		if r.RunId == run.RunId || (r.State != model.RunSuccess && r.State != model.RunFailed) {
+			continue
+		}
+		if previous == model.RunRunning {
+			previous = r.State
+		}
+		if counting == false || r.State != model.RunFailed {
+			break
+		}
+		failures++
+	}
+	return failures, previous
+}
+
+func newMessage(rule *model.AlertRuleEntity, j *model.JobEntity, run *model.RunEntity) *Message {
+	msg := &Message{
+		Rule:      rule.Name,
+		Trigger:   rule.Trigger,
+		Namespace: j.Namespace,
+		JobId:     j.JobId,
+		JobName:   j.Name,
+		RunId:     run.RunId,
+		Error:     run.Error,
+		Link:      runLink(j.Namespace, run.RunId),
+	}
+	if run.StartAt != nil {
+		msg.StartAt = *run.StartAt
+	}
+	return msg
+}
+
+func (a *Alerter) notify(rule *model.AlertRuleEntity, msg *Message) {
+	log := logger.With("ruleId", rule.RuleId, logger.JobIdField, msg.JobId, "runId", msg.RunId)
+	for _, id := range rule.Channels {
+		ch, err := a.store.GetChannel(id)
+		if err != nil {
+			log.With("channelId", id).Error("load alert channel error:", err)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = Send(ctx, &ch, msg)
+		cancel()
+		if err != nil {
+			log.With("channelId", id).Error("send alert error:", err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"traitor/dao/model"
+)
+
+type fakeStore struct {
+	rules    []model.AlertRuleEntity
+	channels map[string]model.ChannelEntity
+	runs     []model.RunEntity // newest first.
+}
+
+func (f *fakeStore) GetAlertRules() ([]model.AlertRuleEntity, error) {
+	return f.rules, nil
+}
+
+func (f *fakeStore) GetChannel(channelId string) (model.ChannelEntity, error) {
+	ch, ok := f.channels[channelId]
+	if ok == false {
+		return ch, errors.New("channel is not exists")
+	}
+	return ch, nil
+}
+
+func (f *fakeStore) GetRuns(_ string) ([]model.RunEntity, error) {
+	return f.runs, nil
+}
+
+func Test_RunFinished(t *testing.T) {
+	received := make(chan map[string]any, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+	}))
+	defer srv.Close()
+
+	store := &fakeStore{
+		channels: map[string]model.ChannelEntity{"hook": {ChannelId: "hook", Type: model.WebhookChannel, Url: srv.URL}},
+		rules: []model.AlertRuleEntity{
+			{Name: "consecutive", Trigger: model.TriggerConsecutive, Threshold: 3, Groups: []string{"nightly"}, Channels: []string{"hook"}},
+			{Name: "recovery", Trigger: model.TriggerRecovery, Channels: []string{"hook"}},
+			{Name: "other job", Trigger: model.TriggerFailure, JobIds: []string{"other"}, Channels: []string{"hook"}},
+			{Name: "other group", Trigger: model.TriggerFailure, Groups: []string{"other"}, Channels: []string{"hook"}},
+		},
+	}
+	a := New(store)
+	j := &model.JobEntity{JobId: "job", Name: "backup", Group: "nightly"}
+	now := time.Now()
+	finish := func(id string, state uint8) {
+		run := model.RunEntity{RunId: id, JobId: "job", State: state, StartAt: &now}
+		if state == model.RunFailed {
+			run.Error = "boom"
+		}
+		store.runs = append([]model.RunEntity{run}, store.runs...)
+		a.RunFinished(j, &run)
+	}
+	expect := func(rule string) {
+		select {
+		case body := <-received:
+			if body["rule"] != rule || strings.Contains(body["text"].(string), "backup") == false {
+				t.Fatalf("unexpected alert %v, want %s", body, rule)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("alert %s is not sent", rule)
+		}
+	}
+
+	finish("1", model.RunFailed)
+	finish("2", model.RunFailed)
+	finish("3", model.RunFailed)
+	expect("consecutive")
+	finish("4", model.RunFailed) // only the threshold-th failure alerts.
+	finish("5", model.RunSuccess)
+	expect("recovery")
+	finish("6", model.RunSuccess)
+	select {
+	case body := <-received:
+		t.Fatalf("unexpected alert %v", body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func Test_render(t *testing.T) {
+	msg := &Message{Title: "job failed", JobName: "backup", JobId: "job", RunId: "run", Error: "boom", Link: "/api/runs/run"}
+	text, err := render(&model.ChannelEntity{}, msg)
+	if err != nil || strings.HasPrefix(text, "[traitor] job failed\n") == false || strings.Contains(text, "error: boom") == false {
+		t.Errorf("unexpected text %q %v", text, err)
+	}
+	text, err = render(&model.ChannelEntity{Template: "{{.JobName}}: {{.Error}}"}, msg)
+	if err != nil || text != "backup: boom" {
+		t.Errorf("unexpected text %q %v", text, err)
+	}
+}
+
+func Test_emailTimeout(t *testing.T) {
+	// a server accepting the connection but never greeting.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	addr := l.Addr().(*net.TCPAddr)
+	ch := &model.ChannelEntity{Type: model.EmailChannel, Smtp: &model.SmtpSettings{Host: "127.0.0.1", Port: addr.Port, From: "a@b.c", To: []string{"d@e.f"}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err = Send(ctx, ch, &Message{Title: "job failed"}); err == nil {
+		t.Fatal("Send() to a silent server succeeded")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Send() returned after %v, want the deadline of ctx", time.Since(start))
+	}
+}
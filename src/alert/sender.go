@@ -0,0 +1,174 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"traitor/dao/model"
+)
+
+func checkUrl(ch *model.ChannelEntity) error {
+	u, err := url.Parse(ch.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid webhook url")
+	}
+	return nil
+}
+
+// postJson POST the body to the url, a status other than 2xx is an error.
+func postJson(ctx context.Context, url string, headers map[string]string, body any) error {
+	buffer, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buffer))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(res.Body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return nil
+}
+
+// webhook POST the message with the rendered text.
+type webhook struct{}
+
+func (webhook) Check(ch *model.ChannelEntity) error {
+	return checkUrl(ch)
+}
+
+func (webhook) Send(ctx context.Context, ch *model.ChannelEntity, msg *Message, text string) error {
+	return postJson(ctx, ch.Url, ch.Headers, struct {
+		*Message
+		Text string `json:"text"`
+	}{msg, text})
+}
+
+type slack struct{}
+
+func (slack) Check(ch *model.ChannelEntity) error {
+	return checkUrl(ch)
+}
+
+func (slack) Send(ctx context.Context, ch *model.ChannelEntity, _ *Message, text string) error {
+	return postJson(ctx, ch.Url, nil, map[string]any{"text": text})
+}
+
+type dingTalk struct{}
+
+func (dingTalk) Check(ch *model.ChannelEntity) error {
+	return checkUrl(ch)
+}
+
+func (dingTalk) Send(ctx context.Context, ch *model.ChannelEntity, _ *Message, text string) error {
+	return postJson(ctx, ch.Url, nil, map[string]any{"msgtype": "text", "text": map[string]string{"content": text}})
+}
+
+type feishu struct{}
+
+func (feishu) Check(ch *model.ChannelEntity) error {
+	return checkUrl(ch)
+}
+
+func (feishu) Send(ctx context.Context, ch *model.ChannelEntity, _ *Message, text string) error {
+	return postJson(ctx, ch.Url, nil, map[string]any{"msg_type": "text", "content": map[string]string{"text": text}})
+}
+
+// email send a plain text mail, the first line of the text is the subject.
+type email struct{}
+
+func (email) Check(ch *model.ChannelEntity) error {
+	c := ch.Smtp
+	if c == nil || c.Host == "" || c.Port <= 0 || c.From == "" || len(c.To) == 0 {
+		return errors.New("smtp host, port, from and to are required")
+	}
+	return nil
+}
+
+func (email) Send(ctx context.Context, ch *model.ChannelEntity, _ *Message, text string) error {
+	c := ch.Smtp
+	subject, body, _ := strings.Cut(text, "\n")
+	var sb strings.Builder
+	sb.WriteString("From: " + c.From + "\r\n")
+	sb.WriteString("To: " + strings.Join(c.To, ", ") + "\r\n")
+	sb.WriteString("Subject: " + subject + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	return sendMail(ctx, addr, c.Host, auth, c.From, c.To, []byte(sb.String()))
+}
+
+// sendMail smtp.SendMail bound to ctx, the whole conversation has to finish before its deadline.
+func sendMail(ctx context.Context, addr string, host string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func(client *smtp.Client) {
+		_ = client.Close()
+	}(client)
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err = client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err = client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
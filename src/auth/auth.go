@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"traitor/dao/model"
+)
+
+// roles, each one may do what the roles before it may do.
+const (
+	Viewer   = "viewer"   // read jobs, scripts and runs.
+	Operator = "operator" // enable, disable and trigger jobs, cancel runs.
+	Editor   = "editor"   // change jobs and scripts, debug.
+	Admin    = "admin"    // manage users and tokens.
+)
+
+var ranks = map[string]int{Viewer: 1, Operator: 2, Editor: 3, Admin: 4}
+
+// ValidRole whether the role is known.
+func ValidRole(role string) bool {
+	_, ok := ranks[role]
+	return ok
+}
+
+// Allows whether the role may do what the required role may do.
+func Allows(role string, required string) bool {
+	return ranks[role] > 0 && ranks[role] >= ranks[required]
+}
+
+// Principal who is calling the API.
+type Principal struct {
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Source string `json:"source"` // how it's authenticated, token/oidc/admin.
+	// Namespaces the principal could access, empty for all of them.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// CanAccess whether the principal could access the namespace.
+func (p Principal) CanAccess(ns string) bool {
+	if len(p.Namespaces) == 0 {
+		return true
+	}
+	for _, n := range p.Namespaces {
+		if n == ns {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrInvalidToken    = errors.New("invalid token")
+)
+
+const tokenPrefix = "trt_"
+
+// NewToken generate the secret of an API token and the hash to store.
+func NewToken() (secret string, hash string) {
+	buffer := make([]byte, 24)
+	_, _ = rand.Read(buffer)
+	secret = tokenPrefix + hex.EncodeToString(buffer)
+	return secret, HashToken(secret)
+}
+
+func HashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Store where the tokens and their users are loaded, it's implemented by dao.Dao.
+type Store interface {
+	GetTokenByHash(hash string) (model.TokenEntity, error)
+	GetUser(name string) (model.UserEntity, error)
+}
+
+// Config how the callers are authenticated.
+type Config struct {
+	// AdminToken a static token with the admin role, to bootstrap the users.
+	AdminToken string
+	Oidc       OidcConfig
+}
+
+type Authenticator struct {
+	store      Store
+	adminToken string
+	oidc       *oidcVerifier
+}
+
+func New(store Store, c Config) *Authenticator {
+	a := &Authenticator{store: store, adminToken: c.AdminToken}
+	if c.Oidc.Issuer != "" {
+		a.oidc = newOidcVerifier(c.Oidc)
+	}
+	return a
+}
+
+// Authenticate the bearer credential, an API token or an OIDC id token.
+func (a *Authenticator) Authenticate(ctx context.Context, bearer string) (Principal, error) {
+	if bearer == "" {
+		return Principal{}, ErrUnauthenticated
+	}
+	if a.adminToken != "" && subtle.ConstantTimeCompare([]byte(bearer), []byte(a.adminToken)) == 1 {
+		return Principal{Name: Admin, Role: Admin, Source: "admin"}, nil
+	}
+	if strings.HasPrefix(bearer, tokenPrefix) {
+		return a.token(bearer)
+	}
+	if a.oidc != nil {
+		return a.oidc.verify(ctx, bearer)
+	}
+	return Principal{}, ErrInvalidToken
+}
+
+func (a *Authenticator) token(secret string) (Principal, error) {
+	t, err := a.store.GetTokenByHash(HashToken(secret))
+	if err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+	if t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()) {
+		return Principal{}, errors.New("token expired")
+	}
+	u, err := a.store.GetUser(t.User)
+	if err != nil || u.Disabled {
+		return Principal{}, errors.New("user is disabled or removed")
+	}
+	return Principal{Name: u.Name, Role: u.Role, Source: "token", Namespaces: u.Namespaces}, nil
+}
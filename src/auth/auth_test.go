@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"traitor/dao/model"
+)
+
+type fakeStore struct {
+	tokens map[string]model.TokenEntity // by hash.
+	users  map[string]model.UserEntity
+}
+
+func (f *fakeStore) GetTokenByHash(hash string) (model.TokenEntity, error) {
+	t, ok := f.tokens[hash]
+	if ok == false {
+		return t, errors.New("token is not exists")
+	}
+	return t, nil
+}
+
+func (f *fakeStore) GetUser(name string) (model.UserEntity, error) {
+	u, ok := f.users[name]
+	if ok == false {
+		return u, errors.New("user is not exists")
+	}
+	return u, nil
+}
+
+func Test_token(t *testing.T) {
+	secret, hash := NewToken()
+	expired, expiredHash := NewToken()
+	past := time.Now().Add(-time.Hour)
+	store := &fakeStore{
+		tokens: map[string]model.TokenEntity{
+			hash:        {User: "bob"},
+			expiredHash: {User: "bob", ExpiresAt: &past},
+		},
+		users: map[string]model.UserEntity{"bob": {Name: "bob", Role: Operator}},
+	}
+	a := New(store, Config{AdminToken: "root"})
+	p, err := a.Authenticate(context.Background(), secret)
+	if err != nil || p.Name != "bob" || Allows(p.Role, Operator) == false || Allows(p.Role, Editor) {
+		t.Errorf("unexpected principal %+v %v", p, err)
+	}
+	if p, err = a.Authenticate(context.Background(), "root"); err != nil || p.Role != Admin {
+		t.Errorf("unexpected principal %+v %v", p, err)
+	}
+	for _, bearer := range []string{"", expired, secret + "0", "eyJ.x.y"} {
+		if _, err = a.Authenticate(context.Background(), bearer); err == nil {
+			t.Errorf("%q should be rejected", bearer)
+		}
+	}
+}
+
+func Test_oidc(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var issuer string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/.well-known/openid-configuration" {
+			_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": issuer + "/keys"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "k1", "kty": "RSA",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer srv.Close()
+	issuer = srv.URL
+
+	sign := func(claims map[string]any) string {
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+		payload, _ := json.Marshal(claims)
+		signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(signed))
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+	a := New(&fakeStore{}, Config{Oidc: OidcConfig{Issuer: issuer, Audience: "traitor"}})
+
+	p, err := a.Authenticate(context.Background(), sign(map[string]any{
+		"iss": issuer, "aud": []string{"traitor"}, "exp": exp, "sub": "alice", "roles": []string{"viewer", "editor", "other"},
+		"namespaces": []string{"team-a"},
+	}))
+	if err != nil || p.Name != "alice" || p.Role != Editor || p.CanAccess("team-a") == false || p.CanAccess("default") {
+		t.Errorf("unexpected principal %+v %v", p, err)
+	}
+	rejected := []map[string]any{
+		{"iss": issuer, "aud": "other", "exp": exp, "sub": "alice", "roles": "editor", "namespaces": "*"},
+		{"iss": issuer, "aud": "traitor", "exp": time.Now().Add(-time.Hour).Unix(), "sub": "alice", "roles": "editor", "namespaces": "*"},
+		{"iss": "https://evil", "aud": "traitor", "exp": exp, "sub": "alice", "roles": "editor", "namespaces": "*"},
+		{"iss": issuer, "aud": "traitor", "exp": exp, "sub": "alice", "roles": "editor"}, // no namespace.
+		{"iss": issuer, "aud": "traitor", "exp": exp, "sub": "alice", "namespaces": "*"},
+	}
+	for _, claims := range rejected {
+		if _, err = a.Authenticate(context.Background(), sign(claims)); err == nil {
+			t.Errorf("%v should be rejected", claims)
+		}
+	}
+	token := sign(map[string]any{"iss": issuer, "aud": "traitor", "exp": exp, "sub": "alice", "roles": "admin", "namespaces": "*"})
+	if p, err = a.Authenticate(context.Background(), token); err != nil || p.Namespaces != nil {
+		t.Errorf("unexpected principal %+v %v", p, err)
+	}
+	if _, err = a.Authenticate(context.Background(), token[:len(token)-4]+"AAAA"); err == nil {
+		t.Error("a forged signature should be rejected")
+	}
+	// signed with the public key as a HMAC secret.
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "kid": "k1"})
+	payload, _ := json.Marshal(map[string]any{"iss": issuer, "aud": "traitor", "exp": exp, "sub": "alice", "roles": "admin"})
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, key.PublicKey.N.Bytes())
+	mac.Write([]byte(signed))
+	if _, err = a.Authenticate(context.Background(), signed+"."+base64.RawURLEncoding.EncodeToString(mac.Sum(nil))); err == nil {
+		t.Error("a HMAC token should be rejected")
+	}
+	// without an audience configured no token is accepted.
+	a = New(&fakeStore{}, Config{Oidc: OidcConfig{Issuer: issuer}})
+	if _, err = a.Authenticate(context.Background(), token); err == nil {
+		t.Error("a token should be rejected without an audience")
+	}
+}
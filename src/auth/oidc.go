@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"traitor/jwt"
+)
+
+const (
+	keysMaxAge     = time.Hour
+	keysMinRefresh = time.Minute // an unknown kid doesn't refresh the keys more often.
+	clockSkew      = time.Minute
+)
+
+// OidcConfig the issuer of the JWT bearer tokens.
+type OidcConfig struct {
+	Issuer   string
+	Audience string // the aud claim should contain it, it's required.
+	// RoleClaim the claim holding the role, a string or a list of strings, the highest known role is used.
+	RoleClaim string
+	// NamespaceClaim the claim holding the namespaces the bearer could access, * for all of them.
+	// a token without any is refused.
+	NamespaceClaim string
+}
+
+// oidcVerifier verify RS256 and ES256 tokens against the keys published by the issuer.
+type oidcVerifier struct {
+	config    OidcConfig
+	client    *http.Client
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func newOidcVerifier(c OidcConfig) *oidcVerifier {
+	if c.RoleClaim == "" {
+		c.RoleClaim = "roles"
+	}
+	if c.NamespaceClaim == "" {
+		c.NamespaceClaim = "namespaces"
+	}
+	c.Issuer = strings.TrimSuffix(c.Issuer, "/")
+	return &oidcVerifier{config: c, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (o *oidcVerifier) verify(ctx context.Context, raw string) (Principal, error) {
+	token, err := jwt.Parse(raw)
+	if err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+	key, err := o.key(ctx, token.Header.Kid)
+	if err != nil {
+		return Principal{}, err
+	}
+	// only the keys of the issuer are accepted, so a HMAC alg never verifies.
+	if token.Verify(key) != nil {
+		return Principal{}, ErrInvalidToken
+	}
+	return o.principal(token)
+}
+
+func (o *oidcVerifier) principal(token *jwt.Token) (Principal, error) {
+	claims := token.Claims
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != o.config.Issuer {
+		return Principal{}, errors.New("unexpected issuer")
+	}
+	if _, ok := claims["exp"].(float64); ok == false {
+		return Principal{}, jwt.ErrExpired
+	}
+	if err := token.CheckTime(time.Now(), clockSkew); err != nil {
+		return Principal{}, err
+	}
+	// a token of another client of the issuer is not for this server, the audience is required.
+	if o.config.Audience == "" || contains(claims["aud"], o.config.Audience) == false {
+		return Principal{}, errors.New("unexpected audience")
+	}
+	p := Principal{Source: "oidc"}
+	for _, role := range stringList(claims[o.config.RoleClaim]) {
+		if ranks[role] > ranks[p.Role] {
+			p.Role = role
+		}
+	}
+	if p.Role == "" {
+		return Principal{}, errors.New("no role is granted")
+	}
+	namespaces := stringList(claims[o.config.NamespaceClaim])
+	if len(namespaces) == 0 {
+		return Principal{}, errors.New("no namespace is granted")
+	}
+	if contains(claims[o.config.NamespaceClaim], "*") == false {
+		p.Namespaces = namespaces
+	}
+	for _, name := range []string{"preferred_username", "email", "sub"} {
+		if p.Name, _ = claims[name].(string); p.Name != "" {
+			break
+		}
+	}
+	return p, nil
+}
+
+// key the public key of the kid, the keys are fetched again if it's unknown or they are too old.
+func (o *oidcVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key, ok := o.keys[kid]
+	age := time.Since(o.fetchedAt)
+	if ok && age < keysMaxAge {
+		return key, nil
+	}
+	if ok == false && age < keysMinRefresh {
+		return nil, ErrInvalidToken
+	}
+	keys, err := o.fetchKeys(ctx)
+	if err != nil {
+		if ok { // keep using the known key when the issuer is down.
+			return key, nil
+		}
+		return nil, fmt.Errorf("fetch the keys of the issuer: %w", err)
+	}
+	o.keys, o.fetchedAt = keys, time.Now()
+	if key, ok = keys[kid]; ok == false {
+		return nil, ErrInvalidToken
+	}
+	return key, nil
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (o *oidcVerifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	var discovery struct {
+		JwksUri string `json:"jwks_uri"`
+	}
+	err := o.getJson(ctx, o.config.Issuer+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err = o.getJson(ctx, discovery.JwksUri, &set)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (o *oidcVerifier) getJson(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err1 := decodeInt(k.N)
+		e, err2 := decodeInt(k.E)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("invalid rsa key")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		x, err1 := decodeInt(k.X)
+		y, err2 := decodeInt(k.Y)
+		if k.Crv != "P-256" || err1 != nil || err2 != nil {
+			return nil, errors.New("unsupported ec key")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	buffer, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(buffer), nil
+}
+
+// stringList a claim of a string or a list of strings.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		res := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func contains(claim any, s string) bool {
+	for _, v := range stringList(claim) {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
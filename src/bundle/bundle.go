@@ -0,0 +1,146 @@
+// Package bundle the portable form of jobs, to move them between servers or keep them as a backup.
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"traitor/dao/model"
+)
+
+// Version of the bundle format.
+const Version = 1
+
+// the task types by name.
+var taskTypes = map[string]uint8{"SCRIPT": model.ScriptTask, "COMMAND": model.CommandTask, "HTTP": model.HttpTask}
+
+// Bundle the jobs of a namespace, in json or yaml.
+type Bundle struct {
+	Version    int       `json:"version" yaml:"version"`
+	ExportedAt time.Time `json:"exportedAt" yaml:"exportedAt"`
+	Namespace  string    `json:"namespace,omitempty" yaml:"namespace,omitempty"` // where the jobs are exported from.
+	Jobs       []Job     `json:"jobs" yaml:"jobs"`
+}
+
+// Job the definition of a job, without its state on the server.
+type Job struct {
+	Id          string             `json:"id,omitempty" yaml:"id,omitempty"`
+	Name        string             `json:"name" yaml:"name"`
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Group       string             `json:"group,omitempty" yaml:"group,omitempty"`
+	Cron        string             `json:"cron,omitempty" yaml:"cron,omitempty"` // a TIMING job.
+	At          *time.Time         `json:"at,omitempty" yaml:"at,omitempty"`     // a DELAY job.
+	Enabled     bool               `json:"enabled" yaml:"enabled"`
+	Task        string             `json:"task" yaml:"task"` // SCRIPT, COMMAND or HTTP.
+	Command     *model.Command     `json:"command,omitempty" yaml:"command,omitempty"`
+	Http        *model.HttpRequest `json:"http,omitempty" yaml:"http,omitempty"`
+	Script      string             `json:"script,omitempty" yaml:"script,omitempty"`
+	Params      map[string]any     `json:"params,omitempty" yaml:"params,omitempty"` // the global `params` of the script.
+}
+
+// FromEntity the definition of the job, its Script should be filled.
+func FromEntity(j model.JobEntity) Job {
+	res := Job{
+		Id:          j.JobId,
+		Name:        j.Name,
+		Description: j.Description,
+		Group:       j.Group,
+		Enabled:     j.State == model.Runnable,
+		Task:        "SCRIPT",
+		Command:     j.Command,
+		Http:        j.Http,
+		Script:      j.Script,
+		Params:      j.Params,
+	}
+	for name, t := range taskTypes {
+		if t == j.TaskType {
+			res.Task = name
+		}
+	}
+	if j.ExecType == model.DelayExecute {
+		if j.ExecAt != nil {
+			at := j.ExecAt.ToTime()
+			res.At = &at
+		}
+	} else {
+		res.Cron = j.Cron
+	}
+	// only the settings of its task are kept.
+	if j.TaskType != model.CommandTask {
+		res.Command = nil
+	}
+	if j.TaskType != model.HttpTask {
+		res.Http = nil
+	}
+	if j.TaskType != model.ScriptTask {
+		res.Script = ""
+	}
+	return res
+}
+
+// Entity the job to be saved, its time and task settings are still to be checked by the server.
+func (j Job) Entity() (model.JobEntity, error) {
+	res := model.JobEntity{
+		JobId:       j.Id,
+		Name:        j.Name,
+		Description: j.Description,
+		Group:       j.Group,
+		Cron:        j.Cron,
+		Command:     j.Command,
+		Http:        j.Http,
+		Script:      j.Script,
+		State:       model.Stop,
+	}
+	if j.Enabled {
+		res.State = model.Runnable
+	}
+	if len(j.Params) > 0 {
+		res.Params = stringKeys(j.Params).(map[string]any)
+	}
+	task := j.Task
+	if task == "" {
+		task = "SCRIPT"
+	}
+	t, ok := taskTypes[task]
+	if ok == false {
+		return res, fmt.Errorf("invalid task %q", j.Task)
+	}
+	res.TaskType = t
+	switch {
+	case j.Cron != "" && j.At != nil:
+		return res, errors.New("either cron or at is expected, not both")
+	case j.At != nil:
+		at := model.TimeStamp(*j.At)
+		res.ExecAt = &at
+		res.ExecType = model.DelayExecute
+	default:
+		res.ExecType = model.TimingExecute
+	}
+	return res, nil
+}
+
+// stringKeys the maps decoded from yaml as map[string]any, like they are from json.
+func stringKeys(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		res := make(map[string]any, len(v))
+		for k, value := range v {
+			res[fmt.Sprint(k)] = stringKeys(value)
+		}
+		return res
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, value := range v {
+			res[k] = stringKeys(value)
+		}
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, value := range v {
+			res[i] = stringKeys(value)
+		}
+		return res
+	default:
+		return v
+	}
+}
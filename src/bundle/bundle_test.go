@@ -0,0 +1,36 @@
+package bundle
+
+import (
+	"testing"
+	"time"
+	"traitor/dao/model"
+)
+
+func TestJob_roundTrip(t *testing.T) {
+	at := model.TimeStamp(time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC))
+	jobs := []model.JobEntity{
+		{JobId: "a", Name: "a", Cron: "0 0 2 * * * *", ExecType: model.TimingExecute, TaskType: model.ScriptTask, Script: "1", State: model.Runnable},
+		{JobId: "b", Name: "b", ExecAt: &at, ExecType: model.DelayExecute, TaskType: model.CommandTask, Command: &model.Command{Args: []string{"echo"}}, Script: "dropped"},
+	}
+	for _, j := range jobs {
+		got, err := FromEntity(j).Entity()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.JobId != j.JobId || got.Cron != j.Cron || got.ExecType != j.ExecType || got.TaskType != j.TaskType || got.State != j.State {
+			t.Errorf("Entity() = %+v, want %+v", got, j)
+		}
+		if j.TaskType == model.CommandTask && (got.Script != "" || got.Command == nil) {
+			t.Errorf("Entity() kept the settings of another task: %+v", got)
+		}
+		if j.ExecAt != nil && (got.ExecAt == nil || got.ExecAt.ToTime().Equal(j.ExecAt.ToTime()) == false) {
+			t.Errorf("Entity().ExecAt = %v, want %v", got.ExecAt, j.ExecAt)
+		}
+	}
+	if _, err := (Job{Cron: "* * * * * * *", At: &time.Time{}}).Entity(); err == nil {
+		t.Error("Entity() with both cron and at succeeded")
+	}
+	if _, err := (Job{Task: "SHELL"}).Entity(); err == nil {
+		t.Error("Entity() with an invalid task succeeded")
+	}
+}
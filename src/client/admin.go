@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"traitor/auth"
+	"traitor/dao/model"
+)
+
+// Me who the client is authenticated as, auth is false if the server has the authentication disabled.
+func (c *Client) Me(ctx context.Context) (p auth.Principal, enabled bool, err error) {
+	var res struct {
+		Data auth.Principal `json:"data"`
+		Auth bool           `json:"auth"`
+	}
+	err = c.do(ctx, http.MethodGet, "/api/me", nil, nil, &res)
+	return res.Data, res.Auth, err
+}
+
+// Users the users.
+func (c *Client) Users(ctx context.Context) ([]model.UserEntity, error) {
+	var res data[[]model.UserEntity]
+	err := c.do(ctx, http.MethodGet, "/api/users", nil, nil, &res)
+	return res.Data, err
+}
+
+// CreateUser create the user.
+func (c *Client) CreateUser(ctx context.Context, u model.UserEntity) error {
+	return c.do(ctx, http.MethodPost, "/api/users", nil, u, nil)
+}
+
+// UserUpdate the changes of UpdateUser, Namespaces empty for all of them.
+type UserUpdate struct {
+	Role       string   `json:"role"`
+	Disabled   bool     `json:"disabled"`
+	Namespaces []string `json:"namespaces"`
+}
+
+// UpdateUser change the role and namespaces of the user, or disable it.
+func (c *Client) UpdateUser(ctx context.Context, name string, u UserUpdate) error {
+	return c.do(ctx, http.MethodPut, path("api", "users", name), nil, u, nil)
+}
+
+// RemoveUser remove the user and its tokens.
+func (c *Client) RemoveUser(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, path("api", "users", name), nil, nil, nil)
+}
+
+// Tokens the tokens of the user, without their secrets.
+func (c *Client) Tokens(ctx context.Context, user string) ([]model.TokenEntity, error) {
+	var res data[[]model.TokenEntity]
+	err := c.do(ctx, http.MethodGet, path("api", "users", user, "tokens"), nil, nil, &res)
+	return res.Data, err
+}
+
+// CreateToken create a token of the user expiring in days, 0 for never, the secret is only returned here.
+func (c *Client) CreateToken(ctx context.Context, user string, name string, days int64) (id string, secret string, err error) {
+	body := map[string]any{"name": name, "expiresIn": days}
+	var res data[struct {
+		TokenId string `json:"tokenId"`
+		Token   string `json:"token"`
+	}]
+	err = c.do(ctx, http.MethodPost, path("api", "users", user, "tokens"), nil, body, &res)
+	return res.Data.TokenId, res.Data.Token, err
+}
+
+// RemoveToken remove the token.
+func (c *Client) RemoveToken(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, path("api", "tokens", id), nil, nil, nil)
+}
+
+// Namespaces the namespaces the client could access.
+func (c *Client) Namespaces(ctx context.Context) ([]model.NamespaceEntity, error) {
+	var res data[[]model.NamespaceEntity]
+	err := c.do(ctx, http.MethodGet, "/api/namespaces", nil, nil, &res)
+	return res.Data, err
+}
+
+// CreateNamespace create the namespace.
+func (c *Client) CreateNamespace(ctx context.Context, n model.NamespaceEntity) error {
+	return c.do(ctx, http.MethodPost, "/api/namespaces", nil, n, nil)
+}
+
+// UpdateNamespace change the description and quotas of the namespace.
+func (c *Client) UpdateNamespace(ctx context.Context, name string, n model.NamespaceEntity) error {
+	return c.do(ctx, http.MethodPut, path("api", "namespaces", name), nil, n, nil)
+}
+
+// RemoveNamespace remove the namespace, it must be empty.
+func (c *Client) RemoveNamespace(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, path("api", "namespaces", name), nil, nil, nil)
+}
+
+// Audit the audit entries of the namespace matching the filter, newest first.
+func (c *Client) Audit(ctx context.Context, f model.AuditFilter) ([]model.AuditEntity, error) {
+	v := url.Values{}
+	for name, value := range map[string]string{"jobId": f.JobId, "actor": f.Actor, "action": f.Action} {
+		if value != "" {
+			v.Set(name, value)
+		}
+	}
+	if f.Since != nil {
+		v.Set("since", f.Since.Format(time.RFC3339))
+	}
+	if f.Until != nil {
+		v.Set("until", f.Until.Format(time.RFC3339))
+	}
+	if f.Limit > 0 {
+		v.Set("limit", strconv.Itoa(f.Limit))
+	}
+	var res data[[]model.AuditEntity]
+	err := c.do(ctx, http.MethodGet, "/api/audit", v, nil, &res)
+	return res.Data, err
+}
+
+// Cluster the live nodes and the jobs they own.
+func (c *Client) Cluster(ctx context.Context) (model.ClusterInfo, error) {
+	var res data[model.ClusterInfo]
+	err := c.do(ctx, http.MethodGet, "/api/cluster", nil, nil, &res)
+	return res.Data, err
+}
+
+// Plugin a loaded plugin.
+type Plugin struct {
+	Name     string    `json:"name,omitempty"`
+	File     string    `json:"file"`
+	Type     string    `json:"type,omitempty"`
+	LoadTime time.Time `json:"loadTime"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Plugins the loaded plugins.
+func (c *Client) Plugins(ctx context.Context) ([]Plugin, error) {
+	var res data[[]Plugin]
+	err := c.do(ctx, http.MethodGet, "/api/plugins", nil, nil, &res)
+	return res.Data, err
+}
+
+// Ready nil if the server could serve and schedule jobs, otherwise an Error of 503.
+func (c *Client) Ready(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/readyz", nil, nil, nil)
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"traitor/dao/model"
+)
+
+// Channels the alert channels, they hold credentials so the editor role is required.
+func (c *Client) Channels(ctx context.Context) ([]model.ChannelEntity, error) {
+	var res data[[]model.ChannelEntity]
+	err := c.do(ctx, http.MethodGet, "/api/alerts/channels", nil, nil, &res)
+	return res.Data, err
+}
+
+// CreateChannel create the channel and return its id.
+func (c *Client) CreateChannel(ctx context.Context, ch model.ChannelEntity) (string, error) {
+	var res data[string]
+	err := c.do(ctx, http.MethodPost, "/api/alerts/channels", nil, ch, &res)
+	return res.Data, err
+}
+
+// UpdateChannel replace the channel.
+func (c *Client) UpdateChannel(ctx context.Context, id string, ch model.ChannelEntity) error {
+	return c.do(ctx, http.MethodPut, path("api", "alerts", "channels", id), nil, ch, nil)
+}
+
+// RemoveChannel remove the channel, it fails while a rule uses it.
+func (c *Client) RemoveChannel(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, path("api", "alerts", "channels", id), nil, nil, nil)
+}
+
+// TestChannel send a sample alert through the channel.
+func (c *Client) TestChannel(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, path("api", "alerts", "channels", id, "test"), nil, nil, nil)
+}
+
+// Rules the alert rules.
+func (c *Client) Rules(ctx context.Context) ([]model.AlertRuleEntity, error) {
+	var res data[[]model.AlertRuleEntity]
+	err := c.do(ctx, http.MethodGet, "/api/alerts/rules", nil, nil, &res)
+	return res.Data, err
+}
+
+// CreateRule create the rule and return its id.
+func (c *Client) CreateRule(ctx context.Context, rule model.AlertRuleEntity) (string, error) {
+	var res data[string]
+	err := c.do(ctx, http.MethodPost, "/api/alerts/rules", nil, rule, &res)
+	return res.Data, err
+}
+
+// UpdateRule replace the rule.
+func (c *Client) UpdateRule(ctx context.Context, id string, rule model.AlertRuleEntity) error {
+	return c.do(ctx, http.MethodPut, path("api", "alerts", "rules", id), nil, rule, nil)
+}
+
+// RemoveRule remove the rule.
+func (c *Client) RemoveRule(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, path("api", "alerts", "rules", id), nil, nil, nil)
+}
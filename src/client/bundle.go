@@ -0,0 +1,94 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"traitor/bundle"
+	"traitor/dao/model"
+)
+
+// the conflicts of Import, how a job whose id is taken is handled.
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+)
+
+// ImportItem what the import does, or would do, with a job of the bundle.
+type ImportItem struct {
+	Id      string         `json:"id,omitempty"` // in the bundle.
+	Name    string         `json:"name"`
+	Action  string         `json:"action"` // create, update, unchanged, skip, rename or invalid.
+	JobId   string         `json:"jobId,omitempty"`
+	Changes []model.Change `json:"changes,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// ImportResult the items of an import, they are also returned with the Error of an invalid bundle.
+type ImportResult struct {
+	Items  []ImportItem `json:"data"`
+	DryRun bool         `json:"dryRun"`
+}
+
+func exportQuery(ids []string, group string, format string) url.Values {
+	v := url.Values{"format": {format}}
+	if len(ids) > 0 {
+		v.Set("id", strings.Join(ids, ","))
+	}
+	if group != "" {
+		v.Set("group", group)
+	}
+	return v
+}
+
+// Export the bundle of the jobs with the ids and in the group, every job if neither is given.
+func (c *Client) Export(ctx context.Context, ids []string, group string) (bundle.Bundle, error) {
+	var b bundle.Bundle
+	err := c.do(ctx, http.MethodGet, "/api/export", exportQuery(ids, group, "json"), nil, &b)
+	return b, err
+}
+
+// ExportFile the bundle as a json or yaml file.
+func (c *Client) ExportFile(ctx context.Context, ids []string, group string, format string) ([]byte, error) {
+	res, err := c.request(ctx, http.MethodGet, "/api/export", exportQuery(ids, group, format), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	return io.ReadAll(res.Body)
+}
+
+// Import the jobs of the bundle, nothing is saved with dryRun.
+func (c *Client) Import(ctx context.Context, b bundle.Bundle, conflict string, dryRun bool) (ImportResult, error) {
+	buffer, err := json.Marshal(b)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	return c.ImportFile(ctx, buffer, "application/json", conflict, dryRun)
+}
+
+// ImportFile import a bundle file, contentType is application/json or application/yaml.
+func (c *Client) ImportFile(ctx context.Context, file []byte, contentType string, conflict string, dryRun bool) (ImportResult, error) {
+	var result ImportResult
+	q := url.Values{"conflict": {conflict}, "dryRun": {strconv.FormatBool(dryRun)}}
+	res, err := c.send(ctx, http.MethodPost, "/api/import", q, contentType, bytes.NewReader(file))
+	if err != nil {
+		return result, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return result, json.NewDecoder(res.Body).Decode(&result)
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError:
+		buffer, _ := io.ReadAll(res.Body)
+		_ = json.Unmarshal(buffer, &result) // the items if it's an invalid bundle.
+		res.Body = io.NopCloser(bytes.NewReader(buffer))
+	}
+	return result, decodeError(res)
+}
@@ -0,0 +1,163 @@
+// Package client the Go client of the traitor API, see server/openapi.json for the routes.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls the API of a traitor server, it's safe for concurrent use.
+type Client struct {
+	baseUrl   string
+	token     string
+	namespace string
+	http      *http.Client
+}
+
+// New the client of the server at baseUrl, e.g. http://127.0.0.1:8080, token is empty if the authentication is disabled.
+func New(baseUrl string, token string) *Client {
+	return &Client{
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		token:   token,
+		http:    http.DefaultClient,
+	}
+}
+
+// WithNamespace a copy of the client working in the namespace.
+func (c *Client) WithNamespace(ns string) *Client {
+	cp := *c
+	cp.namespace = ns
+	return &cp
+}
+
+// WithHttpClient a copy of the client sending the requests through h.
+func (c *Client) WithHttpClient(h *http.Client) *Client {
+	cp := *c
+	cp.http = h
+	return &cp
+}
+
+// BaseUrl the url of the server.
+func (c *Client) BaseUrl() string {
+	return c.baseUrl
+}
+
+// Namespace the namespace of the calls, empty for default.
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// Error the API answered with a status other than 200.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("traitor: %d %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound the error is a 404 of the API.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// data the envelope of most responses.
+type data[T any] struct {
+	Data T `json:"data"`
+}
+
+func (c *Client) request(ctx context.Context, method string, path string, query url.Values, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		buffer, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(buffer)
+	}
+	res, err := c.send(ctx, method, path, query, "application/json", reader)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, decodeError(res)
+	}
+	return res, nil
+}
+
+// send the request whatever the status of the response is.
+func (c *Client) send(ctx context.Context, method string, path string, query url.Values, contentType string, body io.Reader) (*http.Response, error) {
+	u := c.baseUrl + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.namespace != "" {
+		req.Header.Set("X-Namespace", c.namespace)
+	}
+	return c.http.Do(req)
+}
+
+// do send the request and decode the response into out, out is nil if the body is not needed.
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body any, out any) error {
+	res, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// decodeError the message is the error or err of the body, or the body itself if it's a string.
+func decodeError(res *http.Response) error {
+	e := &Error{StatusCode: res.StatusCode}
+	buffer, _ := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	var body struct {
+		Error string `json:"error"`
+		Err   string `json:"err"`
+	}
+	var raw string
+	if json.Unmarshal(buffer, &body) == nil {
+		e.Message = body.Error
+		if e.Message == "" {
+			e.Message = body.Err
+		}
+	} else if json.Unmarshal(buffer, &raw) == nil {
+		e.Message = raw
+	}
+	if e.Message == "" {
+		e.Message = strings.ToLower(http.StatusText(res.StatusCode))
+	}
+	return e
+}
+
+// path escape the segments of the path.
+func path(segments ...string) string {
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return "/" + strings.Join(segments, "/")
+}
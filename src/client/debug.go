@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/url"
+	"strings"
+	"traitor/js_module/debugger"
+)
+
+// Debug run the draft, or the saved script of the job if the draft is empty, in a debug session of the server,
+// fn is called with every event until the run finished.
+func (c *Client) Debug(ctx context.Context, jobId string, draft string, params map[string]any, fn func(debugger.Event)) error {
+	u := c.baseUrl + "/api/debug?" + url.Values{"id": {jobId}, "protocol": {"json"}}.Encode()
+	u = "ws" + strings.TrimPrefix(u, "http") // http to ws, https to wss.
+	header := http.Header{}
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.namespace != "" {
+		header.Set("X-Namespace", c.namespace)
+	}
+	ws, res, err := websocket.DefaultDialer.DialContext(ctx, u, header)
+	if err != nil {
+		if res != nil && res.StatusCode != http.StatusSwitchingProtocols {
+			defer res.Body.Close()
+			return decodeError(res)
+		}
+		return err
+	}
+	defer ws.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = ws.Close() // unblock the read.
+		case <-done:
+		}
+	}()
+
+	err = ws.WriteJSON(debugger.Command{Cmd: debugger.CmdStart, Script: draft, Params: params})
+	if err != nil {
+		return err
+	}
+	for {
+		var e debugger.Event
+		if err = ws.ReadJSON(&e); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		fn(e)
+		if e.Event == debugger.EventFinished {
+			return nil
+		}
+	}
+}
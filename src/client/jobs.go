@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"traitor/dao/model"
+)
+
+// the execution types of CreateJob and RunJob.
+const (
+	Timing = "TIMING"
+	Delay  = "DELAY"
+)
+
+// JobQuery the filters, order and page of Jobs, the zero value is the first page of every job by name.
+type JobQuery struct {
+	Q        string // text found in the name or description.
+	State    string // RUNNABLE or STOP.
+	Type     string // TIMING or DELAY.
+	TaskType string // SCRIPT, COMMAND or HTTP.
+	Group    string
+	Sort     string // name or next, - in front for descending.
+	Limit    int
+	Cursor   string // the Next of the previous page.
+}
+
+func (q JobQuery) values() url.Values {
+	v := url.Values{}
+	for name, value := range map[string]string{
+		"q": q.Q, "state": q.State, "type": q.Type, "taskType": q.TaskType,
+		"group": q.Group, "sort": q.Sort, "cursor": q.Cursor,
+	} {
+		if value != "" {
+			v.Set(name, value)
+		}
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return v
+}
+
+// Job a job of the listing.
+type Job struct {
+	model.JobEntity
+	NextFireTime *time.Time `json:"nextFireTime"` // nil if the job won't fire.
+}
+
+// JobPage a page of Jobs, Next is empty after the last page.
+type JobPage struct {
+	Data []Job  `json:"data"`
+	Next string `json:"next"`
+}
+
+// Jobs a page of the jobs matching the query.
+func (c *Client) Jobs(ctx context.Context, q JobQuery) (JobPage, error) {
+	var page JobPage
+	err := c.do(ctx, http.MethodGet, "/api/jobs", q.values(), nil, &page)
+	return page, err
+}
+
+// JobList every job of the namespace in one response.
+func (c *Client) JobList(ctx context.Context) ([]model.JobEntity, error) {
+	var jobs []model.JobEntity
+	err := c.do(ctx, http.MethodGet, "/api/jobList", nil, nil, &jobs)
+	return jobs, err
+}
+
+// GetJob the job without its script.
+func (c *Client) GetJob(ctx context.Context, id string) (model.JobEntity, error) {
+	var res data[model.JobEntity]
+	err := c.do(ctx, http.MethodGet, "/api/job", url.Values{"id": {id}}, nil, &res)
+	return res.Data, err
+}
+
+// CreateJob create a stopped job of the execution type, Timing or Delay, and return its id.
+func (c *Client) CreateJob(ctx context.Context, execType string, job model.JobEntity) (string, error) {
+	var res data[string]
+	err := c.do(ctx, http.MethodPost, "/api/job", url.Values{"type": {execType}}, job, &res)
+	return res.Data, err
+}
+
+// RunJob create a job and enable it at once.
+func (c *Client) RunJob(ctx context.Context, execType string, job model.JobEntity) (string, error) {
+	var res data[string]
+	err := c.do(ctx, http.MethodPost, "/api/run", url.Values{"type": {execType}}, job, &res)
+	return res.Data, err
+}
+
+// UpdateJob change the given fields of the job, the cron or execAt is always required.
+func (c *Client) UpdateJob(ctx context.Context, id string, fields map[string]any) error {
+	return c.do(ctx, http.MethodPut, "/api/job", url.Values{"id": {id}}, fields, nil)
+}
+
+// RemoveJob remove the job and its runs.
+func (c *Client) RemoveJob(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/job", url.Values{"id": {id}}, nil, nil)
+}
+
+// EnableJob enable or disable the job.
+func (c *Client) EnableJob(ctx context.Context, id string, enable bool) error {
+	q := url.Values{"id": {id}, "enable": {strconv.FormatBool(enable)}}
+	return c.do(ctx, http.MethodPost, "/api/enable", q, nil, nil)
+}
+
+// TriggerJob run the job once now, the id of the run is returned.
+func (c *Client) TriggerJob(ctx context.Context, id string) (string, error) {
+	var res data[string]
+	err := c.do(ctx, http.MethodPost, "/api/trigger", url.Values{"id": {id}}, nil, &res)
+	return res.Data, err
+}
+
+// GetScript the script of the job.
+func (c *Client) GetScript(ctx context.Context, id string) (string, error) {
+	var res data[model.ScriptEntity]
+	err := c.do(ctx, http.MethodGet, "/api/script", url.Values{"id": {id}}, nil, &res)
+	return res.Data.Script, err
+}
+
+// UpdateScript save the script of the job as a new revision.
+func (c *Client) UpdateScript(ctx context.Context, id string, script string) error {
+	body := map[string]string{"script": script}
+	return c.do(ctx, http.MethodPost, "/api/script", url.Values{"id": {id}}, body, nil)
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"traitor/dao/model"
+)
+
+// Runs the latest runs of the job, newest first, q filters by the output or error and may be empty.
+func (c *Client) Runs(ctx context.Context, jobId string, q string) ([]model.RunEntity, error) {
+	v := url.Values{"id": {jobId}}
+	if q != "" {
+		v.Set("q", q)
+	}
+	var res data[[]model.RunEntity]
+	err := c.do(ctx, http.MethodGet, "/api/runs", v, nil, &res)
+	return res.Data, err
+}
+
+// GetRun the run.
+func (c *Client) GetRun(ctx context.Context, runId string) (model.RunEntity, error) {
+	var res data[model.RunEntity]
+	err := c.do(ctx, http.MethodGet, path("api", "runs", runId), nil, nil, &res)
+	return res.Data, err
+}
+
+// ActiveRuns the runs of the namespace in flight, oldest first.
+func (c *Client) ActiveRuns(ctx context.Context) ([]model.RunEntity, error) {
+	var res data[[]model.RunEntity]
+	err := c.do(ctx, http.MethodGet, "/api/runs/active", nil, nil, &res)
+	return res.Data, err
+}
+
+// CancelRun cancel the run in flight.
+func (c *Client) CancelRun(ctx context.Context, runId string) error {
+	return c.do(ctx, http.MethodPost, path("api", "runs", runId, "cancel"), nil, nil, nil)
+}
+
+// StreamRun call fn with every line of the console output until the run finished, fetch the run for its state.
+func (c *Client) StreamRun(ctx context.Context, runId string, fn func(line string)) error {
+	res, err := c.request(ctx, http.MethodGet, path("api", "runs", runId, "stream"), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	var event string
+	var lines []string
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "": // the end of an event.
+			if event == "end" {
+				return nil
+			}
+			if event == "output" {
+				fn(strings.Join(lines, "\n"))
+			}
+			event, lines = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			lines = append(lines, strings.TrimPrefix(line, "data:"))
+		}
+	}
+	return scanner.Err()
+}
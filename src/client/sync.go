@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"traitor/dao/model"
+)
+
+// SyncItem what the sync did with a manifest, or with a managed job whose manifest is gone.
+type SyncItem struct {
+	File      string         `json:"file,omitempty"`
+	Namespace string         `json:"namespace,omitempty"`
+	JobId     string         `json:"jobId,omitempty"`
+	Action    string         `json:"action"` // create, update, unchanged, release or invalid.
+	Drift     bool           `json:"drift,omitempty"`
+	Changes   []model.Change `json:"changes,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// SyncResult the outcome of a sync of the manifests.
+type SyncResult struct {
+	Dir        string     `json:"dir"`
+	Revision   string     `json:"revision,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt time.Time  `json:"finishedAt"`
+	Error      string     `json:"error,omitempty"`
+	Items      []SyncItem `json:"items"`
+}
+
+// SyncStatus the last sync, Last is nil before the first one.
+type SyncStatus struct {
+	Last     *SyncResult `json:"data"`
+	Interval string      `json:"interval"`
+	Owner    bool        `json:"owner"` // whether the node answering syncs the directory.
+}
+
+// SyncStatus the result of the last sync, a not found error if the server syncs no directory.
+func (c *Client) SyncStatus(ctx context.Context) (SyncStatus, error) {
+	var res SyncStatus
+	err := c.do(ctx, http.MethodGet, "/api/sync", nil, nil, &res)
+	return res, err
+}
+
+// Sync the manifests now.
+func (c *Client) Sync(ctx context.Context) (SyncResult, error) {
+	var res data[SyncResult]
+	err := c.do(ctx, http.MethodPost, "/api/sync", nil, nil, &res)
+	return res.Data, err
+}
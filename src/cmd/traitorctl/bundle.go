@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"traitor/client"
+)
+
+// runExport write the bundle of the jobs to the file, or the stdout.
+func runExport(cl *cli, ctx context.Context, args []string) error {
+	var ids multiFlag
+	var group, format, out string
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Var(&ids, "id", "")
+	fs.StringVar(&group, "group", "", "")
+	fs.StringVar(&format, "format", "", "")
+	fs.StringVar(&out, "out", "", "")
+	if _, err := cl.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if format == "" {
+		format = "yaml"
+		if ext := filepath.Ext(out); ext == ".json" {
+			format = "json"
+		}
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	file, err := c.ExportFile(ctx, ids, group, format)
+	if err != nil {
+		return err
+	}
+	if out == "" || out == "-" {
+		_, err = cl.out.Write(file)
+		return err
+	}
+	return os.WriteFile(out, file, 0644)
+}
+
+// runImport import the bundle file and print what is done with each job.
+func runImport(cl *cli, ctx context.Context, args []string) error {
+	var conflict string
+	var dryRun bool
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.StringVar(&conflict, "conflict", client.ConflictSkip, "")
+	fs.BoolVar(&dryRun, "dry-run", false, "")
+	positional, err := cl.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	file, err := readFile(positional[0])
+	if err != nil {
+		return err
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	result, err := c.ImportFile(ctx, file, bundleType(positional[0], file), conflict, dryRun)
+	if len(result.Items) > 0 {
+		t := table{{"ID", "ACTION", "JOB", "DETAIL"}}
+		for _, item := range result.Items {
+			detail := item.Error
+			if detail == "" {
+				detail = changedFields(item.Changes)
+			}
+			t = append(t, []string{orDash(item.Id), item.Action, orDash(item.JobId), orDash(detail)})
+		}
+		if printErr := cl.print(result, t); printErr != nil {
+			return printErr
+		}
+	}
+	if err == nil && dryRun && cl.output != "json" {
+		fmt.Fprintln(cl.errOut, "dry run, nothing is saved.")
+	}
+	return err
+}
+
+// bundleType the content type of the file, by its extension or else its content.
+func bundleType(name string, file []byte) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return "application/json"
+	case ".yaml", ".yml":
+		return "application/yaml"
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(file), []byte("{")) {
+		return "application/json"
+	}
+	return "application/yaml"
+}
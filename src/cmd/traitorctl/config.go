@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mitchellh/go-homedir"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Context a server traitorctl talks to.
+type Context struct {
+	Server    string `json:"server"`
+	Token     string `json:"token,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Config the contexts of traitorctl, kept in ~/.traitor/traitorctl.json by default.
+type Config struct {
+	Current  string             `json:"current,omitempty"`
+	Contexts map[string]Context `json:"contexts"`
+}
+
+const defaultServer = "http://127.0.0.1:8080"
+
+// defaultConfigPath $TRAITORCTL_CONFIG, or traitorctl.json in ~/.traitor.
+func defaultConfigPath() string {
+	if p := os.Getenv("TRAITORCTL_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := homedir.Dir()
+	if err != nil {
+		return "traitorctl.json"
+	}
+	return filepath.Join(dir, ".traitor", "traitorctl.json")
+}
+
+// loadConfig an empty config if the file is not exists.
+func loadConfig(path string) (*Config, error) {
+	cfg := &Config{Contexts: make(map[string]Context)}
+	buffer, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(buffer, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if cfg.Contexts == nil {
+		cfg.Contexts = make(map[string]Context)
+	}
+	return cfg, nil
+}
+
+// save the config readable by the owner only, it holds tokens.
+func (cfg *Config) save(path string) error {
+	buffer, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(buffer, '\n'), 0600)
+}
+
+// resolve the context named, or the current one if name is empty, the local server if there is none.
+func (cfg *Config) resolve(name string) (Context, error) {
+	if name == "" {
+		name = cfg.Current
+	}
+	if name == "" {
+		return Context{Server: defaultServer}, nil
+	}
+	ctx, ok := cfg.Contexts[name]
+	if ok == false {
+		return Context{}, fmt.Errorf("context %q is not found", name)
+	}
+	return ctx, nil
+}
+
+// names the names of the contexts, sorted.
+func (cfg *Config) names() []string {
+	res := make([]string, 0, len(cfg.Contexts))
+	for name := range cfg.Contexts {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// redacted a copy without the tokens, to be printed.
+func (cfg *Config) redacted() *Config {
+	res := &Config{Current: cfg.Current, Contexts: make(map[string]Context, len(cfg.Contexts))}
+	for name, c := range cfg.Contexts {
+		if c.Token != "" {
+			c.Token = "REDACTED"
+		}
+		res.Contexts[name] = c
+	}
+	return res
+}
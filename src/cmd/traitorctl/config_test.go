@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestConfig_saveAndResolve(t *testing.T) {
+	path := t.TempDir() + "/traitorctl.json"
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := cfg.resolve(""); err != nil || c.Server != defaultServer {
+		t.Fatalf("resolve() = %v, %v, want the local server", c, err)
+	}
+	cfg.Contexts["prod"] = Context{Server: "https://traitor.example.com", Token: "secret", Namespace: "ops"}
+	cfg.Current = "prod"
+	if err = cfg.save(path); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("the config is %v, want 0600", info.Mode().Perm())
+	}
+	loaded, err := loadConfig(path)
+	if err != nil || reflect.DeepEqual(loaded, cfg) == false {
+		t.Fatalf("loadConfig() = %v, %v, want %v", loaded, err, cfg)
+	}
+	if c, _ := loaded.resolve(""); c.Token != "secret" {
+		t.Errorf("resolve() = %v, want the current context", c)
+	}
+	if _, err = loaded.resolve("missing"); err == nil {
+		t.Error("resolve() of a missing context succeeded")
+	}
+}
+
+func Test_parse(t *testing.T) {
+	cl := &cli{output: "table"}
+	fs := flag.NewFlagSet("runs", flag.ContinueOnError)
+	q := fs.String("q", "", "")
+	positional, err := cl.parse(fs, []string{"job-1", "-q", "timeout", "-o", "json"}, 1)
+	if err != nil || reflect.DeepEqual(positional, []string{"job-1"}) == false || *q != "timeout" || cl.output != "json" {
+		t.Fatalf("parse() = %v, %v, q %q, output %q", positional, err, *q, cl.output)
+	}
+	if _, err = cl.parse(flag.NewFlagSet("get", flag.ContinueOnError), nil, 1); err == nil {
+		t.Error("parse() without the job id succeeded")
+	}
+}
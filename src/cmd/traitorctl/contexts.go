@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+// runContext manage the contexts of the config file.
+func runContext(cl *cli, _ context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("context")
+	}
+	switch args[0] {
+	case "list":
+		rows := table{{"CURRENT", "NAME", "SERVER", "NAMESPACE"}}
+		for _, name := range cl.cfg.names() {
+			c := cl.cfg.Contexts[name]
+			current := ""
+			if name == cl.cfg.Current {
+				current = "*"
+			}
+			rows = append(rows, []string{current, name, c.Server, orDash(c.Namespace)})
+		}
+		return cl.print(cl.cfg.redacted(), rows)
+	case "use":
+		if len(args) != 2 {
+			return errors.New("usage: traitorctl context use NAME")
+		}
+		if _, ok := cl.cfg.Contexts[args[1]]; ok == false {
+			return fmt.Errorf("context %q is not found", args[1])
+		}
+		cl.cfg.Current = args[1]
+		return cl.cfg.save(cl.cfgPath)
+	case "set":
+		fs := flag.NewFlagSet("context", flag.ContinueOnError)
+		server := fs.String("server", "", "")
+		token := fs.String("token", "", "")
+		ns := fs.String("namespace", "", "")
+		positional, err := cl.parse(fs, args[1:], 1)
+		if err != nil {
+			return err
+		}
+		name := positional[0]
+		c, ok := cl.cfg.Contexts[name]
+		if ok == false {
+			c.Server = defaultServer
+		}
+		// only the given flags are changed.
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "server":
+				c.Server = *server
+			case "token":
+				c.Token = *token
+			case "namespace":
+				c.Namespace = *ns
+			}
+		})
+		cl.cfg.Contexts[name] = c
+		if cl.cfg.Current == "" {
+			cl.cfg.Current = name
+		}
+		return cl.cfg.save(cl.cfgPath)
+	case "remove":
+		if len(args) != 2 {
+			return errors.New("usage: traitorctl context remove NAME")
+		}
+		if _, ok := cl.cfg.Contexts[args[1]]; ok == false {
+			return fmt.Errorf("context %q is not found", args[1])
+		}
+		delete(cl.cfg.Contexts, args[1])
+		if cl.cfg.Current == args[1] {
+			cl.cfg.Current = ""
+		}
+		return cl.cfg.save(cl.cfgPath)
+	default:
+		return usageError("context")
+	}
+}
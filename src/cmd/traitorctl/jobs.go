@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"traitor/client"
+	"traitor/dao/model"
+)
+
+func runJobs(cl *cli, ctx context.Context, args []string) error {
+	var q client.JobQuery
+	var all bool
+	fs := flag.NewFlagSet("jobs", flag.ContinueOnError)
+	fs.StringVar(&q.Q, "q", "", "")
+	fs.StringVar(&q.State, "state", "", "")
+	fs.StringVar(&q.Type, "type", "", "")
+	fs.StringVar(&q.TaskType, "taskType", "", "")
+	fs.StringVar(&q.Group, "group", "", "")
+	fs.StringVar(&q.Sort, "sort", "", "")
+	fs.IntVar(&q.Limit, "limit", 0, "")
+	fs.BoolVar(&all, "all", false, "")
+	if _, err := cl.parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	jobs := make([]client.Job, 0)
+	for {
+		page, err := c.Jobs(ctx, q)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, page.Data...)
+		if all == false || page.Next == "" {
+			break
+		}
+		q.Cursor = page.Next
+	}
+	rows := table{{"JOB ID", "NAME", "GROUP", "TASK", "SCHEDULE", "STATE", "NEXT FIRE"}}
+	for i := range jobs {
+		j := &jobs[i]
+		rows = append(rows, []string{j.JobId, orDash(j.Name), orDash(j.Group), taskType(j.TaskType),
+			jobSchedule(&j.JobEntity), jobState(j.State), formatTime(j.NextFireTime)})
+	}
+	return cl.print(jobs, rows)
+}
+
+func runGet(cl *cli, ctx context.Context, args []string) error {
+	positional, err := cl.parse(flag.NewFlagSet("get", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	j, err := c.GetJob(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	return cl.print(j, table{
+		{"JOB ID", j.JobId},
+		{"NAME", orDash(j.Name)},
+		{"NAMESPACE", orDash(j.Namespace)},
+		{"GROUP", orDash(j.Group)},
+		{"TASK", taskType(j.TaskType)},
+		{"SCHEDULE", jobSchedule(&j)},
+		{"STATE", jobState(j.State)},
+		{"REVISION", strconv.FormatInt(j.Revision, 10)},
+		{"LAST RUN", formatTime(j.LastExecTime)},
+		{"DESCRIPTION", orDash(j.Description)},
+	})
+}
+
+func runCreate(cl *cli, ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	file := fs.String("f", "", "")
+	execType := fs.String("type", "", "")
+	enable := fs.Bool("enable", false, "")
+	if _, err := cl.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		return usageError("create")
+	}
+	var job model.JobEntity
+	if err := readJson(*file, &job); err != nil {
+		return err
+	}
+	if *execType == "" { // a job with execAt runs once.
+		*execType = client.Timing
+		if job.ExecAt != nil {
+			*execType = client.Delay
+		}
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	create := c.CreateJob
+	if *enable {
+		create = c.RunJob
+	}
+	id, err := create(ctx, *execType, job)
+	if err != nil {
+		return err
+	}
+	return cl.print(map[string]string{"jobId": id}, table{{id}})
+}
+
+func runUpdate(cl *cli, ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	file := fs.String("f", "", "")
+	fs.String(model.Name, "", "")
+	fs.String(model.Cron, "", "")
+	fs.String(model.Description, "", "")
+	fs.String(model.Group, "", "")
+	positional, err := cl.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]any)
+	if *file != "" {
+		if err = readJson(*file, &fields); err != nil {
+			return err
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case model.Name, model.Cron, model.Description, model.Group:
+			fields[f.Name] = f.Value.String()
+		}
+	})
+	if len(fields) == 0 {
+		return errors.New("nothing to update")
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	id := positional[0]
+	_, hasCron := fields[model.Cron]
+	_, hasExecAt := fields[model.ExecAt]
+	if hasCron == false && hasExecAt == false { // the server checks the time settings with every update.
+		j, err := c.GetJob(ctx, id)
+		if err != nil {
+			return err
+		}
+		if j.ExecType == model.DelayExecute {
+			fields[model.ExecAt] = j.ExecAt
+		} else {
+			fields[model.Cron] = j.Cron
+		}
+	}
+	return c.UpdateJob(ctx, id, fields)
+}
+
+func runDelete(cl *cli, ctx context.Context, args []string) error {
+	positional, err := cl.parse(flag.NewFlagSet("delete", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	return c.RemoveJob(ctx, positional[0])
+}
+
+func runEnable(cl *cli, ctx context.Context, args []string) error {
+	return setEnable(cl, ctx, "enable", args, true)
+}
+
+func runDisable(cl *cli, ctx context.Context, args []string) error {
+	return setEnable(cl, ctx, "disable", args, false)
+}
+
+func setEnable(cl *cli, ctx context.Context, name string, args []string, enable bool) error {
+	positional, err := cl.parse(flag.NewFlagSet(name, flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	return c.EnableJob(ctx, positional[0], enable)
+}
+
+// runPush save the local file as the script of the job.
+func runPush(cl *cli, ctx context.Context, args []string) error {
+	positional, err := cl.parse(flag.NewFlagSet("push", flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+	script, err := readFile(positional[1])
+	if err != nil {
+		return err
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	return c.UpdateScript(ctx, positional[0], string(script))
+}
+
+// readFile the file, or the stdin if it's -.
+func readFile(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
+func readJson(name string, v any) error {
+	buffer, err := readFile(name)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(buffer, v); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// traitorctl the command-line client of traitor.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"traitor/client"
+)
+
+// cli the state shared by the commands.
+type cli struct {
+	cfg     *Config
+	cfgPath string
+	context string // the name of the context in use, empty for the current one.
+	server  string
+	token   string
+	ns      string
+	output  string // table or json.
+	out     io.Writer
+	errOut  io.Writer
+}
+
+type command func(cl *cli, ctx context.Context, args []string) error
+
+var commands = map[string]command{
+	"context": runContext,
+	"jobs":    runJobs,
+	"get":     runGet,
+	"create":  runCreate,
+	"update":  runUpdate,
+	"delete":  runDelete,
+	"enable":  runEnable,
+	"disable": runDisable,
+	"trigger": runTrigger,
+	"runs":    runRuns,
+	"logs":    runLogs,
+	"push":    runPush,
+	"debug":   runDebug,
+	"export":  runExport,
+	"import":  runImport,
+	"sync":    runSync,
+}
+
+// usages the arguments of the commands.
+var usages = map[string]string{
+	"context": "context list|use NAME|set NAME [-server URL] [-token TOKEN] [-namespace NS]|remove NAME",
+	"jobs":    "jobs [-q TEXT] [-state RUNNABLE|STOP] [-type TIMING|DELAY] [-taskType T] [-group G] [-sort FIELD] [-limit N] [-all]",
+	"get":     "get JOB_ID",
+	"create":  "create -f JOB.json [-type TIMING|DELAY] [-enable]",
+	"update":  "update JOB_ID [-f FIELDS.json] [-name N] [-cron C] [-description D] [-group G]",
+	"delete":  "delete JOB_ID",
+	"enable":  "enable JOB_ID",
+	"disable": "disable JOB_ID",
+	"trigger": "trigger JOB_ID [-follow]",
+	"runs":    "runs JOB_ID [-q TEXT]",
+	"logs":    "logs RUN_ID",
+	"push":    "push JOB_ID SCRIPT.js",
+	"debug":   "debug JOB_ID [SCRIPT.js] [-param KEY=VALUE]...",
+	"export":  "export [-id JOB_ID]... [-group G] [-format yaml|json] [-out FILE]",
+	"import":  "import FILE [-conflict skip|overwrite|rename] [-dry-run]",
+	"sync":    "sync [-now]",
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "traitorctl:", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer, errOut io.Writer) error {
+	cl := &cli{out: out, errOut: errOut}
+	fs := flag.NewFlagSet("traitorctl", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	fs.StringVar(&cl.cfgPath, "config", defaultConfigPath(), "the config file of the contexts, default is $TRAITORCTL_CONFIG or ~/.traitor/traitorctl.json.")
+	fs.StringVar(&cl.context, "context", "", "the context to use instead of the current one.")
+	fs.StringVar(&cl.server, "server", "", "the url of the server, overrides the context.")
+	fs.StringVar(&cl.token, "token", os.Getenv("TRAITOR_TOKEN"), "the API token, overrides the context. default is $TRAITOR_TOKEN.")
+	fs.StringVar(&cl.ns, "n", "", "the namespace, overrides the context.")
+	fs.StringVar(&cl.output, "o", "table", "output format, table or json.")
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if cl.output != "table" && cl.output != "json" {
+		return fmt.Errorf("invalid output %q", cl.output)
+	}
+	if fs.NArg() == 0 {
+		usage(fs)
+		return errors.New("a command is required")
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if ok == false {
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+	cfg, err := loadConfig(cl.cfgPath)
+	if err != nil {
+		return err
+	}
+	cl.cfg = cfg
+	return cmd(cl, ctx, fs.Args()[1:])
+}
+
+func usage(fs *flag.FlagSet) {
+	fmt.Fprintln(fs.Output(), "usage: traitorctl [flags] command [args]\n\ncommands:")
+	names := make([]string, 0, len(usages))
+	for name := range usages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(fs.Output(), "  "+usages[name])
+	}
+	fmt.Fprintln(fs.Output(), "\nflags:")
+	fs.PrintDefaults()
+}
+
+// client the client of the context, the flags override its fields.
+func (cl *cli) client() (*client.Client, error) {
+	c, err := cl.cfg.resolve(cl.context)
+	if err != nil {
+		return nil, err
+	}
+	if cl.server != "" {
+		c.Server = cl.server
+	}
+	if cl.token != "" {
+		c.Token = cl.token
+	}
+	if cl.ns != "" {
+		c.Namespace = cl.ns
+	}
+	return client.New(c.Server, c.Token).WithNamespace(c.Namespace), nil
+}
+
+// parse the flags of a command, they could be given before or after its arguments,
+// and so could the output, namespace and context flags.
+// the arguments are checked to be want many, -1 for any.
+func (cl *cli) parse(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cl.output, "o", cl.output, "")
+	fs.StringVar(&cl.ns, "n", cl.ns, "")
+	fs.StringVar(&cl.context, "context", cl.context, "")
+	var positional []string
+	for {
+		if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+			return nil, usageError(fs.Name())
+		} else if err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if want >= 0 && len(positional) != want {
+		return nil, usageError(fs.Name())
+	}
+	if cl.output != "table" && cl.output != "json" {
+		return nil, fmt.Errorf("invalid output %q", cl.output)
+	}
+	return positional, nil
+}
+
+// multiFlag a flag that could be given many times.
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *multiFlag) Set(v string) error {
+	*m = append(*m, v)
+	return nil
+}
+
+func usageError(name string) error {
+	return fmt.Errorf("usage: traitorctl %s", usages[name])
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"traitor/dao/model"
+)
+
+// table rows of a table output, the first one is the header.
+type table [][]string
+
+// print v as json, or the table.
+func (cl *cli) print(v any, t table) error {
+	if cl.output == "json" {
+		encoder := json.NewEncoder(cl.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+	w := tabwriter.NewWriter(cl.out, 0, 4, 2, ' ', 0)
+	for _, row := range t {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func jobState(state uint8) string {
+	if state == model.Runnable {
+		return "RUNNABLE"
+	}
+	return "STOP"
+}
+
+func jobSchedule(j *model.JobEntity) string {
+	if j.ExecType == model.DelayExecute {
+		if j.ExecAt == nil {
+			return "-"
+		}
+		t := j.ExecAt.ToTime()
+		return "at " + formatTime(&t)
+	}
+	return j.Cron
+}
+
+func taskType(t uint8) string {
+	switch t {
+	case model.CommandTask:
+		return "COMMAND"
+	case model.HttpTask:
+		return "HTTP"
+	default:
+		return "SCRIPT"
+	}
+}
+
+func runState(state uint8) string {
+	switch state {
+	case model.RunRunning:
+		return "RUNNING"
+	case model.RunSuccess:
+		return "SUCCESS"
+	case model.RunFailed:
+		return "FAILED"
+	case model.RunCancelled:
+		return "CANCELLED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func duration(r *model.RunEntity) string {
+	if r.StartAt == nil || r.EndAt == nil {
+		return "-"
+	}
+	return r.EndAt.Sub(*r.StartAt).Round(time.Millisecond).String()
+}
+
+// changedFields the names of the changed fields, comma separated.
+func changedFields(changes []model.Change) string {
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	return strings.Join(fields, ",")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+	"traitor/client"
+	"traitor/dao/model"
+	"traitor/js_module/debugger"
+)
+
+func runRuns(cl *cli, ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("runs", flag.ContinueOnError)
+	q := fs.String("q", "", "")
+	positional, err := cl.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	runs, err := c.Runs(ctx, positional[0], *q)
+	if err != nil {
+		return err
+	}
+	rows := table{{"RUN ID", "STATE", "STARTED", "DURATION", "NODE", "ERROR"}}
+	for i := range runs {
+		r := &runs[i]
+		rows = append(rows, []string{r.RunId, runState(r.State), formatTime(r.StartAt), duration(r), orDash(r.NodeId), orDash(r.Error)})
+	}
+	return cl.print(runs, rows)
+}
+
+// runLogs print the output of the run, following it while it's running, or the finished run as json.
+func runLogs(cl *cli, ctx context.Context, args []string) error {
+	positional, err := cl.parse(flag.NewFlagSet("logs", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	return cl.logs(ctx, positional[0])
+}
+
+func (cl *cli) logs(ctx context.Context, runId string) error {
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	run, err := c.GetRun(ctx, runId)
+	if err != nil {
+		return err
+	}
+	streamed := false
+	if run.State == model.RunRunning {
+		err = c.StreamRun(ctx, runId, func(line string) {
+			if cl.output == "table" {
+				streamed = true
+				fmt.Fprintln(cl.out, line)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if run, err = c.GetRun(ctx, runId); err != nil {
+			return err
+		}
+	}
+	if cl.output == "json" { // the finished run only.
+		return cl.print(run, nil)
+	}
+	if streamed == false { // only the console of a script is streamed.
+		cl.printOutput(&run)
+	}
+	if run.State != model.RunSuccess {
+		return fmt.Errorf("run %s %s: %s", runId, strings.ToLower(runState(run.State)), orDash(run.Error))
+	}
+	return nil
+}
+
+// printOutput the output of a finished run, by the task type.
+func (cl *cli) printOutput(run *model.RunEntity) {
+	for _, s := range []string{run.Output, run.Stdout, run.Response} {
+		if s != "" {
+			fmt.Fprint(cl.out, strings.TrimSuffix(s, "\n")+"\n")
+		}
+	}
+	if run.Stderr != "" {
+		fmt.Fprint(cl.errOut, strings.TrimSuffix(run.Stderr, "\n")+"\n")
+	}
+}
+
+// runTrigger run the job once now and print the id of the run, with -follow the logs of the run are printed.
+func runTrigger(cl *cli, ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("trigger", flag.ContinueOnError)
+	follow := fs.Bool("follow", false, "")
+	positional, err := cl.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	runId, err := c.TriggerJob(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	if *follow == false {
+		return cl.print(map[string]string{"runId": runId}, table{{runId}})
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) { // the record is saved when the run starts.
+		_, err := c.GetRun(ctx, runId)
+		if err == nil {
+			return cl.logs(ctx, runId)
+		}
+		if client.IsNotFound(err) == false {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	return errors.New("the run did not start in 30s")
+}
+
+// runDebug run the local script, or the saved one, in a debug session and stream its output.
+func runDebug(cl *cli, ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("debug", flag.ContinueOnError)
+	var params multiFlag
+	fs.Var(&params, "param", "")
+	positional, err := cl.parse(fs, args, -1)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 || len(positional) > 2 {
+		return usageError("debug")
+	}
+	var draft string
+	if len(positional) == 2 {
+		script, err := readFile(positional[1])
+		if err != nil {
+			return err
+		}
+		draft = string(script)
+	}
+	values := make(map[string]any, len(params))
+	for _, p := range params {
+		k, v, ok := strings.Cut(p, "=")
+		if ok == false {
+			return fmt.Errorf("invalid param %q, KEY=VALUE is expected", p)
+		}
+		var value any
+		if json.Unmarshal([]byte(v), &value) != nil { // a json value, otherwise the string.
+			value = v
+		}
+		values[k] = value
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	var failed error
+	err = c.Debug(ctx, positional[0], draft, values, func(e debugger.Event) {
+		switch e.Event {
+		case debugger.EventOutput:
+			fmt.Fprint(cl.out, strings.TrimSuffix(e.Text, "\n")+"\n")
+		case debugger.EventError:
+			failed = errors.New(e.Error)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return failed
+}
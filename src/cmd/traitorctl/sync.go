@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"traitor/client"
+)
+
+// runSync print the last sync of the manifests, or sync them now with -now.
+func runSync(cl *cli, ctx context.Context, args []string) error {
+	var now bool
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	fs.BoolVar(&now, "now", false, "")
+	if _, err := cl.parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := cl.client()
+	if err != nil {
+		return err
+	}
+	var res client.SyncResult
+	if now {
+		if res, err = c.Sync(ctx); err != nil && res.StartedAt.IsZero() {
+			return err
+		}
+	} else {
+		status, err := c.SyncStatus(ctx)
+		if err != nil {
+			return err
+		}
+		if cl.output == "json" {
+			return cl.print(status, nil)
+		}
+		if status.Last == nil {
+			return errors.New("no sync yet, it's done every " + status.Interval)
+		}
+		res = *status.Last
+	}
+	if cl.output != "json" {
+		fmt.Fprintf(cl.out, "%s %s at %s\n", res.Dir, orDash(res.Revision), formatTime(&res.FinishedAt))
+		if res.Error != "" {
+			fmt.Fprintln(cl.out, "error:", res.Error)
+		}
+	}
+	t := table{{"FILE", "NAMESPACE", "JOB", "ACTION", "DETAIL"}}
+	for _, item := range res.Items {
+		detail := item.Error
+		if detail == "" {
+			detail = changedFields(item.Changes)
+		}
+		action := item.Action
+		if item.Drift {
+			action += " (drift)"
+		}
+		t = append(t, []string{orDash(item.File), orDash(item.Namespace), orDash(item.JobId), action, orDash(detail)})
+	}
+	if printErr := cl.print(res, t); printErr != nil {
+		return printErr
+	}
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, item := range res.Items {
+		if item.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of the manifests failed to sync", failed)
+	}
+	return nil
+}
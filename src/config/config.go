@@ -1,11 +1,55 @@
 package config
 
-func SetupConfig(key string, val string) {
+import (
+	"strings"
+	"sync"
+)
+
+const (
+	CommandEnable = "command.enable" // "true" allows COMMAND jobs.
+	CommandAllow  = "command.allow"  // comma separated executables, "*" for any.
+	WasmHttpAllow = "wasm.httpAllow" // comma separated hosts the wasm plugins may request, "*" for any.
+	PublicUrl     = "public.url"     // the url the server is reached at, used in the links of alerts.
+	AllowOrigins  = "allow.origins"  // comma separated origins the websockets accept besides the same host.
+	TrustProxies  = "trust.proxies"  // comma separated ips or cidrs whose X-Forwarded-For is trusted.
+	AuthEnable    = "auth.enable"    // "true" requires a token or an OIDC bearer for the API.
+	AdminToken    = "auth.admin"     // a static token with the admin role.
+	OidcIssuer    = "oidc.issuer"
+	OidcAudience  = "oidc.audience"
+	OidcRoleClaim = "oidc.roleClaim"
+	OidcNsClaim   = "oidc.namespaceClaim"
+	SyncDir       = "sync.dir"      // the directory of the job manifests, empty to not sync.
+	SyncInterval  = "sync.interval" // a time.Duration between two syncs.
+	SyncPull      = "sync.pull"     // "true" pulls the git checkout before a sync.
+)
+
+var (
+	mu      sync.RWMutex
+	configs = make(map[string]string)
+)
 
+func SetupConfig(key string, val string) {
+	mu.Lock()
+	defer mu.Unlock()
+	configs[key] = val
 }
 
 func GetConfig(key string) string {
-	return ""
+	mu.RLock()
+	defer mu.RUnlock()
+	return configs[key]
+}
+
+// GetConfigList split a comma separated config value.
+func GetConfigList(key string) []string {
+	res := make([]string, 0)
+	for _, v := range strings.Split(GetConfig(key), ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
 }
 
 func IsMultiNodes() bool {
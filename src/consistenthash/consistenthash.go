@@ -32,6 +32,15 @@ func (m *Map) IsEmpty() bool {
 	return len(m.keys) == 0
 }
 
+// Len the number of keys added.
+func (m *Map) Len() int {
+	keys := make(map[string]struct{})
+	for _, key := range m.hashMap {
+		keys[key] = struct{}{}
+	}
+	return len(keys)
+}
+
 // Add some keys to the hash.
 func (m *Map) Add(keys ...string) {
 	for _, key := range keys {
@@ -7,6 +7,8 @@ import (
 )
 
 type Dao interface {
+	// Ping check the storage is reachable.
+	Ping() error
 	GetJobInfos() ([]model.JobEntity, error)
 	GetRunnableJobs() ([]model.JobEntity, error)
 	GetJobInfo(jobId string) (model.JobEntity, error)
@@ -14,11 +16,75 @@ type Dao interface {
 	AddJob(job model.JobEntity) (string, error)
 	UpdateJob(jobId string, mp map[string]any) error
 	RemoveJob(jobId string) error
+
+	AddRun(run model.RunEntity) (string, error)
+	UpdateRun(runId string, mp map[string]any) error
+	GetRun(runId string) (model.RunEntity, error)
+	// GetRuns the latest runs of the job, newest first.
+	GetRuns(jobId string) ([]model.RunEntity, error)
+	// SearchRuns the runs of the job whose output or error contains the keyword, newest first.
+	SearchRuns(jobId string, keyword string) ([]model.RunEntity, error)
+
+	AddChannel(ch model.ChannelEntity) (string, error)
+	UpdateChannel(ch model.ChannelEntity) error
+	RemoveChannel(channelId string) error
+	GetChannel(channelId string) (model.ChannelEntity, error)
+	GetChannels() ([]model.ChannelEntity, error)
+	AddAlertRule(rule model.AlertRuleEntity) (string, error)
+	UpdateAlertRule(rule model.AlertRuleEntity) error
+	RemoveAlertRule(ruleId string) error
+	GetAlertRule(ruleId string) (model.AlertRuleEntity, error)
+	GetAlertRules() ([]model.AlertRuleEntity, error)
+
+	AddUser(u model.UserEntity) error
+	UpdateUser(u model.UserEntity) error
+	// RemoveUser remove the user and its tokens.
+	RemoveUser(name string) error
+	GetUser(name string) (model.UserEntity, error)
+	GetUsers() ([]model.UserEntity, error)
+	AddToken(t model.TokenEntity) (string, error)
+	RemoveToken(tokenId string) error
+	GetToken(tokenId string) (model.TokenEntity, error)
+	GetTokenByHash(hash string) (model.TokenEntity, error)
+	GetTokens(user string) ([]model.TokenEntity, error)
+
+	// AddAudit append an entry, the entries are never updated or removed.
+	AddAudit(a model.AuditEntity) error
+	// GetAudits the entries passing the filter, newest first.
+	GetAudits(f model.AuditFilter) ([]model.AuditEntity, error)
+
+	// Namespace the dao of the jobs, runs, alerts and audit entries of the namespace.
+	// users, tokens and namespaces are shared by all of them.
+	Namespace(ns string) Dao
+	AddNamespace(n model.NamespaceEntity) error
+	UpdateNamespace(n model.NamespaceEntity) error
+	RemoveNamespace(name string) error
+	// GetNamespace the default namespace always exists.
+	GetNamespace(name string) (model.NamespaceEntity, error)
+	// GetNamespaces the default namespace first.
+	GetNamespaces() ([]model.NamespaceEntity, error)
+}
+
+// the stores return their own type for a namespace, these turn it back into a Dao.
+type mongoDao struct {
+	*mongoStoreage.MongoDao
+}
+
+func (m mongoDao) Namespace(ns string) Dao {
+	return mongoDao{m.MongoDao.Namespace(ns)}
+}
+
+type localDao struct {
+	*localdb.LocalDb
+}
+
+func (l localDao) Namespace(ns string) Dao {
+	return localDao{l.LocalDb.Namespace(ns)}
 }
 
 func CreateMongoDao(uri string, cluster string) Dao {
-	return mongoStoreage.CreateMongoDao(uri, cluster)
+	return mongoDao{mongoStoreage.CreateMongoDao(uri, cluster)}
 }
 func CreateLocalDao() Dao {
-	return localdb.CreateLocalDao()
+	return localDao{localdb.CreateLocalDao()}
 }
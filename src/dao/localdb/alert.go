@@ -0,0 +1,85 @@
+package localdb
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"traitor/dao/model"
+)
+
+const (
+	channel_key_prefix = "alert_channel_"
+	channel_keys_set   = "alert_channel_keys_set"
+	rule_key_prefix    = "alert_rule_"
+	rule_keys_set      = "alert_rule_keys_set"
+)
+
+// channels and rules are stored as json, see saveJson.
+func (l *LocalDb) AddChannel(ch model.ChannelEntity) (string, error) {
+	ch.ChannelId = uuid.NewString()
+	return ch.ChannelId, l.saveJson(l.prefix+channel_keys_set, l.prefix+channel_key_prefix, ch.ChannelId, ch)
+}
+
+func (l *LocalDb) UpdateChannel(ch model.ChannelEntity) error {
+	if _, err := l.GetChannel(ch.ChannelId); err != nil {
+		return err
+	}
+	return l.saveJson(l.prefix+channel_keys_set, l.prefix+channel_key_prefix, ch.ChannelId, ch)
+}
+
+func (l *LocalDb) RemoveChannel(channelId string) error {
+	return l.removeJson(l.prefix+channel_keys_set, l.prefix+channel_key_prefix, channelId)
+}
+
+func (l *LocalDb) GetChannel(channelId string) (model.ChannelEntity, error) {
+	var ch model.ChannelEntity
+	err := l.loadJson(l.prefix+channel_key_prefix+channelId, &ch)
+	if err != nil {
+		return ch, errors.New("channel is not exists")
+	}
+	return ch, nil
+}
+
+func (l *LocalDb) GetChannels() ([]model.ChannelEntity, error) {
+	res := make([]model.ChannelEntity, 0)
+	for _, id := range l.members(l.prefix + channel_keys_set) {
+		if ch, err := l.GetChannel(id); err == nil {
+			res = append(res, ch)
+		}
+	}
+	return res, nil
+}
+
+func (l *LocalDb) AddAlertRule(rule model.AlertRuleEntity) (string, error) {
+	rule.RuleId = uuid.NewString()
+	return rule.RuleId, l.saveJson(l.prefix+rule_keys_set, l.prefix+rule_key_prefix, rule.RuleId, rule)
+}
+
+func (l *LocalDb) UpdateAlertRule(rule model.AlertRuleEntity) error {
+	if _, err := l.GetAlertRule(rule.RuleId); err != nil {
+		return err
+	}
+	return l.saveJson(l.prefix+rule_keys_set, l.prefix+rule_key_prefix, rule.RuleId, rule)
+}
+
+func (l *LocalDb) RemoveAlertRule(ruleId string) error {
+	return l.removeJson(l.prefix+rule_keys_set, l.prefix+rule_key_prefix, ruleId)
+}
+
+func (l *LocalDb) GetAlertRule(ruleId string) (model.AlertRuleEntity, error) {
+	var rule model.AlertRuleEntity
+	err := l.loadJson(l.prefix+rule_key_prefix+ruleId, &rule)
+	if err != nil {
+		return rule, errors.New("alert rule is not exists")
+	}
+	return rule, nil
+}
+
+func (l *LocalDb) GetAlertRules() ([]model.AlertRuleEntity, error) {
+	res := make([]model.AlertRuleEntity, 0)
+	for _, id := range l.members(l.prefix + rule_keys_set) {
+		if rule, err := l.GetAlertRule(id); err == nil {
+			res = append(res, rule)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,44 @@
+package localdb
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"traitor/dao/model"
+	"traitor/db/protocol"
+	utils "traitor/db/util"
+)
+
+const audit_log = "audit_log"
+
+// the entries are json strings pushed to a list, newest first.
+func (l *LocalDb) AddAudit(a model.AuditEntity) error {
+	a.AuditId = uuid.NewString()
+	buffer, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	if _, ok := l.client.Send(utils.ToCmdLine("LPUSH", l.prefix+audit_log, string(buffer))).(*protocol.IntReply); ok == false {
+		return errors.New("add audit failed")
+	}
+	return nil
+}
+
+func (l *LocalDb) GetAudits(f model.AuditFilter) ([]model.AuditEntity, error) {
+	res := make([]model.AuditEntity, 0)
+	reply, ok := l.client.Send(utils.ToCmdLine("LRANGE", l.prefix+audit_log, "0", "-1")).(*protocol.MultiBulkReply)
+	if ok == false {
+		return res, nil
+	}
+	for _, arg := range reply.Args {
+		var a model.AuditEntity
+		if json.Unmarshal(arg, &a) != nil || f.Match(&a) == false {
+			continue
+		}
+		res = append(res, a)
+		if f.Limit > 0 && len(res) == f.Limit {
+			break
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,51 @@
+package localdb
+
+import (
+	"encoding/json"
+	"errors"
+	"traitor/db/protocol"
+	utils "traitor/db/util"
+)
+
+// entities without a fixed layout are stored as json strings, the ids are kept in a set.
+func (l *LocalDb) saveJson(set string, prefix string, id string, v any) error {
+	buffer, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	reply := l.client.Send(utils.ToCmdLine("SET", prefix+id, string(buffer)))
+	if status, ok := reply.(*protocol.StatusReply); ok == false || status.IsOKReply() == false {
+		return errors.New("save failed")
+	}
+	if _, ok := l.client.Send(utils.ToCmdLine("SADD", set, id)).(*protocol.IntReply); ok == false {
+		return errors.New("save failed")
+	}
+	return nil
+}
+
+func (l *LocalDb) loadJson(key string, v any) error {
+	bulk, ok := l.client.Send(utils.ToCmdLine("GET", key)).(*protocol.BulkReply)
+	if ok == false {
+		return errors.New("not exists")
+	}
+	return json.Unmarshal(bulk.Arg, v)
+}
+
+func (l *LocalDb) removeJson(set string, prefix string, id string) error {
+	reply := l.client.Send(utils.ToCmdLine("SREM", set, id))
+	if intReply, ok := reply.(*protocol.IntReply); ok == false || intReply.Code != 1 {
+		return errors.New("remove failed")
+	}
+	l.client.Send(utils.ToCmdLine("DEL", prefix+id))
+	return nil
+}
+
+func (l *LocalDb) members(set string) []string {
+	res := make([]string, 0)
+	if keys, ok := l.client.Send(utils.ToCmdLine("SMEMBERS", set)).(*protocol.MultiBulkReply); ok {
+		for _, key := range keys.Args {
+			res = append(res, string(key))
+		}
+	}
+	return res
+}
@@ -2,10 +2,13 @@ package localdb
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"github.com/fatih/structs"
 	"github.com/google/uuid"
+	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 	"traitor/dao/model"
@@ -13,10 +16,14 @@ import (
 	"traitor/db/protocol"
 	"traitor/db/startup"
 	utils "traitor/db/util"
+	"traitor/metrics"
 )
 
 type LocalDb struct {
 	client *client.Client
+	// namespace of the jobs, runs, alerts and audit entries, their keys are prefixed by prefix.
+	namespace string
+	prefix    string
 }
 
 var dbClient *client.Client
@@ -28,6 +35,10 @@ func createClient() *client.Client {
 
 		cnnFac, _ := startup.Startup(ctx)
 		dbClient = cnnFac()
+		metrics.RegisterStore(func() metrics.StoreStats {
+			stats := startup.GetStats()
+			return metrics.StoreStats{Keys: stats.Keys, TtlKeys: stats.TtlKeys, AofQueue: stats.AofQueue}
+		})
 	})
 	return dbClient
 }
@@ -38,10 +49,40 @@ func CreateLocalDao() *LocalDb {
 	}
 }
 
-const job_keys_set = "job_keys_set"
+const (
+	job_keys_set     = "job_keys_set"
+	namespace_prefix = "ns_" // the keys of a namespace other than the default one start with ns_<ns>:.
+)
+
+var errForeignKey = errors.New("the job id is a key of another namespace")
+
+// Namespace the dao keeping the keys of the namespace, users, tokens and namespaces are shared.
+func (l *LocalDb) Namespace(ns string) *LocalDb {
+	if ns == "" || ns == model.DefaultNamespace { // the keys from before namespaces.
+		return &LocalDb{client: l.client, namespace: model.DefaultNamespace}
+	}
+	return &LocalDb{client: l.client, namespace: ns, prefix: namespace_prefix + ns + ":"}
+}
+
+// jobKey the key of the hash of the job, an error if it's a key of another namespace.
+// the jobs of the default namespace are kept under their bare ids, so these ids are refused.
+func (l *LocalDb) jobKey(jobId string) (string, error) {
+	if strings.HasPrefix(jobId, namespace_prefix) {
+		return "", errForeignKey
+	}
+	return l.prefix + jobId, nil
+}
+
+func (l *LocalDb) Ping() error {
+	reply := l.client.Send(utils.ToCmdLine("PING"))
+	if r, ok := reply.(*protocol.StatusReply); ok == false || r.Status != "PONG" {
+		return errors.New("local db is not reachable")
+	}
+	return nil
+}
 
 func (l *LocalDb) GetJobInfos() ([]model.JobEntity, error) {
-	args := utils.ToCmdLine("SMembers", job_keys_set)
+	args := utils.ToCmdLine("SMembers", l.prefix+job_keys_set)
 	reply := l.client.Send(args)
 	var keys *protocol.MultiBulkReply
 	switch reply.(type) {
@@ -57,13 +98,13 @@ func (l *LocalDb) GetJobInfos() ([]model.JobEntity, error) {
 		panic("unknown error:localdb job_keys_set was changed")
 	}
 
-	var res = make([]model.JobEntity, len(keys.Args))
-	for i, key := range keys.Args {
+	var res = make([]model.JobEntity, 0, len(keys.Args))
+	for _, key := range keys.Args {
 		entity, err := l.GetJobInfo(string(key))
 		if err != nil {
 			continue
 		}
-		res[i] = entity
+		res = append(res, entity)
 	}
 	return res, nil
 }
@@ -93,42 +134,47 @@ func (l *LocalDb) GetRunnableJobs() ([]model.JobEntity, error) {
 		result[i] = j
 		i++
 	}
-	return result, nil
+	return result[:i], nil
 }
 
 func (l *LocalDb) GetJobInfo(jobId string) (model.JobEntity, error) {
-
-	cmd := utils.ToCmdLine("HMGET", jobId, model.Name, model.Cron, model.LastExecTime, model.State, model.Description,
-		model.ExecType, model.ExecAt)
+	key, err := l.jobKey(jobId)
+	if err != nil {
+		return model.JobEntity{}, errors.New("jobId is not exists")
+	}
+	cmd := utils.ToCmdLine("HMGET", key, model.Name, model.Cron, model.LastExecTime, model.State, model.Description,
+		model.ExecType, model.ExecAt, model.TaskType, model.CommandField, model.HttpField, model.Revision, model.Group,
+		model.Params, model.ManagedBy)
 	reply := l.client.Send(cmd)
 	multiBulkReply, ok := reply.(*protocol.MultiBulkReply)
 	if ok == false {
 		return model.JobEntity{}, errors.New("jobId is not exists")
 	}
-	var mp, err = toMap(multiBulkReply.Args, model.Name, model.Cron, model.LastExecTime, model.State, model.Description,
-		model.ExecType, model.ExecAt)
+	mp, err := toMap(multiBulkReply.Args, model.Name, model.Cron, model.LastExecTime, model.State, model.Description,
+		model.ExecType, model.ExecAt, model.TaskType, model.CommandField, model.HttpField, model.Revision, model.Group,
+		model.Params, model.ManagedBy)
 	if err != nil {
 		return model.JobEntity{}, err
 	}
+	if mp[model.State] == "" { // the state is always saved, HMGET of a missing key gives nils.
+		return model.JobEntity{}, errors.New("jobId is not exists")
+	}
 
 	var entity = model.JobEntity{
 		JobId:       jobId,
+		Namespace:   l.namespace,
 		Name:        mp[model.Name],
 		Cron:        mp[model.Cron],
 		Description: mp[model.Description],
+		Group:       mp[model.Group],
+		ManagedBy:   mp[model.ManagedBy],
 	}
-	if mp[model.LastExecTime] != "" {
-		t, err := time.Parse("2017-08-30 16:40:41", mp[model.LastExecTime])
-		if err != nil {
-			entity.LastExecTime = &t
-		}
+	if t, err := parseTime(mp[model.LastExecTime]); err == nil {
+		entity.LastExecTime = &t
 	}
-	if mp[model.ExecAt] != "" {
-		t, err := time.Parse("2017-08-30 16:40:41", mp[model.ExecAt])
+	if t, err := time.ParseInLocation(execAtLayout, mp[model.ExecAt], time.Local); err == nil {
 		var ts = model.TimeStamp(t)
-		if err != nil {
-			entity.ExecAt = &ts
-		}
+		entity.ExecAt = &ts
 	}
 	state, err := strconv.ParseUint(mp[model.State], 10, 8)
 	if err == nil {
@@ -138,13 +184,55 @@ func (l *LocalDb) GetJobInfo(jobId string) (model.JobEntity, error) {
 	if err == nil {
 		entity.ExecType = uint8(exeType)
 	}
+	taskType, err := strconv.ParseUint(mp[model.TaskType], 10, 8)
+	if err == nil {
+		entity.TaskType = uint8(taskType)
+	}
+	revision, err := strconv.ParseInt(mp[model.Revision], 10, 64)
+	if err == nil {
+		entity.Revision = revision
+	}
+	if mp[model.CommandField] != "" {
+		var command model.Command
+		if json.Unmarshal([]byte(mp[model.CommandField]), &command) == nil {
+			entity.Command = &command
+		}
+	}
+	if mp[model.HttpField] != "" {
+		var request model.HttpRequest
+		if json.Unmarshal([]byte(mp[model.HttpField]), &request) == nil {
+			entity.Http = &request
+		}
+	}
+	if mp[model.Params] != "" {
+		var params map[string]any
+		if json.Unmarshal([]byte(mp[model.Params]), &params) == nil && len(params) > 0 {
+			entity.Params = params
+		}
+	}
 
 	return entity, nil
 }
+
+// execAtLayout the layout of model.TimeStamp.ToString, in local time.
+const execAtLayout = "2006-01-02 15:04:05"
+
+// parseTime parse the output of time.Time.String, which the times are saved as.
+func parseTime(v string) (time.Time, error) {
+	if i := strings.Index(v, " m="); i >= 0 { // the monotonic clock reading.
+		v = v[:i]
+	}
+	return time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", v)
+}
+
 func (l *LocalDb) GetJobScript(jobId string) (model.ScriptEntity, error) {
-	cmd := utils.ToCmdLine("HGET", jobId, model.Script)
-	reply := l.client.Send(cmd)
 	var result model.ScriptEntity
+	key, err := l.jobKey(jobId)
+	if err != nil {
+		return result, err
+	}
+	cmd := utils.ToCmdLine("HGET", key, model.Script)
+	reply := l.client.Send(cmd)
 	switch reply.(type) {
 	case *protocol.BulkReply:
 		{
@@ -161,38 +249,19 @@ func (l *LocalDb) AddJob(job model.JobEntity) (string, error) {
 	if job.JobId == "" {
 		job.JobId = uuid.NewString()
 	}
-	mp := structs.Map(job)
-	args := make([]string, len(mp)*2+2)
-	args[0] = "HMSET"
-	args[1] = job.JobId
-	i := 2
-	for k, v := range mp {
-		var value string
-		switch v.(type) {
-		case string:
-			value = v.(string)
-		case uint8:
-			value = strconv.FormatUint(uint64(v.(uint8)), 10)
-		case uint64:
-			value = strconv.FormatUint(v.(uint64), 10)
-		case *time.Time:
-			t := v.(*time.Time)
-			if t == nil {
-				continue
-			}
-			value = t.String()
-		case *model.TimeStamp:
-			t := v.(*model.TimeStamp)
-			if t == nil {
-				continue
-			}
-			value = t.ToString()
-		default:
-			continue // ignore
+	key, err := l.jobKey(job.JobId)
+	if err != nil {
+		return job.JobId, err
+	}
+	// HMSET would merge the fields into the hash of an existing job.
+	if exists, ok := l.client.Send(utils.ToCmdLine("EXISTS", key)).(*protocol.IntReply); ok == false || exists.Code != 0 {
+		return job.JobId, errors.New("jobId already exists")
+	}
+	args := []string{"HMSET", key}
+	for k, v := range structs.Map(job) {
+		if value, ok := fieldValue(v); ok {
+			args = append(args, k, value)
 		}
-		args[i] = k
-		args[i+1] = value
-		i += 2
 	}
 	cmd := utils.ToCmdLine(args...)
 	reply := l.client.Send(cmd)
@@ -201,7 +270,7 @@ func (l *LocalDb) AddJob(job model.JobEntity) (string, error) {
 	}
 	setArg := make([]string, 3)
 	setArg[0] = "SADD"
-	setArg[1] = job_keys_set
+	setArg[1] = l.prefix + job_keys_set
 	setArg[2] = job.JobId
 	setCmd := utils.ToCmdLine(setArg...)
 	setReply := l.client.Send(setCmd)
@@ -214,29 +283,16 @@ func (l *LocalDb) UpdateJob(jobId string, mp map[string]any) error {
 	if jobId == "" {
 		return errors.New("job id cannot be empty")
 	}
-	key := jobId
+	key, err := l.jobKey(jobId)
+	if err != nil {
+		return err
+	}
 	delete(mp, model.JobId)
-	args := make([]string, len(mp)*2+2)
-	args[0] = "HMSET"
-	args[1] = key
-	i := 2
+	args := []string{"HMSET", key}
 	for k, v := range mp {
-		var value string
-		switch v.(type) {
-		case string:
-			value = v.(string)
-		case uint8:
-			value = strconv.FormatUint(uint64(v.(uint8)), 10)
-		case uint64:
-			value = strconv.FormatUint(v.(uint64), 10)
-		case time.Time:
-			value = v.(time.Time).String()
-		default:
-			continue // ignore
+		if value, ok := fieldValue(v); ok {
+			args = append(args, k, value)
 		}
-		args[i] = k
-		args[i+1] = value
-		i += 2
 	}
 	cmd := utils.ToCmdLine(args...)
 	reply := l.client.Send(cmd)
@@ -245,10 +301,53 @@ func (l *LocalDb) UpdateJob(jobId string, mp map[string]any) error {
 	}
 	return nil
 }
+
+// fieldValue the hash field a job field is saved as, false if it's nil or of a type not saved.
+func fieldValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64: // numbers decoded from json.
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case time.Time:
+		return v.String(), true
+	case *time.Time:
+		if v == nil {
+			return "", false
+		}
+		return v.String(), true
+	case *model.TimeStamp:
+		if v == nil {
+			return "", false
+		}
+		return v.ToString(), true
+	case *model.Command, *model.HttpRequest, map[string]any, []any:
+		if reflect.ValueOf(v).IsNil() {
+			return "", false
+		}
+		buffer, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(buffer), true
+	default:
+		return "", false
+	}
+}
 func (l *LocalDb) EditJobScript(jobId string, script string) error {
+	key, err := l.jobKey(jobId)
+	if err != nil {
+		return err
+	}
 	args := make([]string, 4)
 	args[0] = "HSET"
-	args[1] = jobId
+	args[1] = key
 	args[2] = model.Script
 	args[3] = script
 	cmd := utils.ToCmdLine(args...)
@@ -260,9 +359,13 @@ func (l *LocalDb) EditJobScript(jobId string, script string) error {
 }
 
 func (l *LocalDb) RemoveJob(jobId string) error {
+	key, err := l.jobKey(jobId)
+	if err != nil {
+		return err
+	}
 	args := make([]string, 3)
 	args[0] = "SREM"
-	args[1] = job_keys_set
+	args[1] = l.prefix + job_keys_set
 	args[2] = jobId
 	cmd := utils.ToCmdLine(args...)
 	reply := l.client.Send(cmd)
@@ -272,11 +375,12 @@ func (l *LocalDb) RemoveJob(jobId string) error {
 
 	args = make([]string, 2)
 	args[0] = "DEL"
-	args[1] = jobId
+	args[1] = key
 	cmd = utils.ToCmdLine(args...)
 	reply = l.client.Send(cmd)
 	if intReply, ok := reply.(*protocol.IntReply); ok == false || intReply.Code != 1 {
 		return errors.New("remove failed")
 	}
+	l.removeRuns(jobId)
 	return nil
 }
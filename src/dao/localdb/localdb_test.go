@@ -17,3 +17,14 @@ func TestName(t *testing.T) {
 	}
 	t.Log(tm)
 }
+
+func Test_parseTime(t *testing.T) {
+	now := time.Now()
+	got, err := parseTime(now.String()) // with the monotonic clock reading.
+	if err != nil || got.Equal(now) == false {
+		t.Errorf("parseTime() = %v, %v, want %v", got, err, now)
+	}
+	if _, err = parseTime(""); err == nil {
+		t.Errorf("parseTime() of nothing should fail")
+	}
+}
@@ -0,0 +1,61 @@
+package localdb
+
+import (
+	"errors"
+	"traitor/dao/model"
+)
+
+const (
+	namespace_key_prefix = "namespace_"
+	namespace_keys_set   = "namespace_keys_set"
+)
+
+// namespaces are stored as json without the prefix of a namespace, see saveJson.
+func (l *LocalDb) AddNamespace(n model.NamespaceEntity) error {
+	if _, err := l.GetNamespace(n.Name); err == nil {
+		return errors.New("namespace already exists")
+	}
+	return l.saveJson(namespace_keys_set, namespace_key_prefix, n.Name, n)
+}
+
+func (l *LocalDb) UpdateNamespace(n model.NamespaceEntity) error {
+	if _, err := l.GetNamespace(n.Name); err != nil {
+		return err
+	}
+	return l.saveJson(namespace_keys_set, namespace_key_prefix, n.Name, n)
+}
+
+func (l *LocalDb) RemoveNamespace(name string) error {
+	if name == model.DefaultNamespace {
+		return errors.New("the default namespace cannot be removed")
+	}
+	return l.removeJson(namespace_keys_set, namespace_key_prefix, name)
+}
+
+// GetNamespace the default namespace exists even if it was never saved.
+func (l *LocalDb) GetNamespace(name string) (model.NamespaceEntity, error) {
+	var n model.NamespaceEntity
+	err := l.loadJson(namespace_key_prefix+name, &n)
+	if err != nil && name == model.DefaultNamespace {
+		return model.NamespaceEntity{Name: model.DefaultNamespace}, nil
+	}
+	if err != nil {
+		return n, errors.New("namespace is not exists")
+	}
+	return n, nil
+}
+
+// GetNamespaces the default namespace first.
+func (l *LocalDb) GetNamespaces() ([]model.NamespaceEntity, error) {
+	def, _ := l.GetNamespace(model.DefaultNamespace)
+	res := []model.NamespaceEntity{def}
+	for _, name := range l.members(namespace_keys_set) {
+		if name == model.DefaultNamespace {
+			continue
+		}
+		if n, err := l.GetNamespace(name); err == nil {
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,133 @@
+package localdb
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"traitor/dao/model"
+	"traitor/db/protocol"
+	utils "traitor/db/util"
+)
+
+const (
+	run_key_prefix  = "run_"
+	job_runs_prefix = "job_runs_"
+)
+
+// runs are stored as json strings, each job keeps a list of its run ids, newest first.
+func (l *LocalDb) AddRun(run model.RunEntity) (string, error) {
+	if run.RunId == "" {
+		run.RunId = uuid.NewString()
+	}
+	err := l.setRun(run)
+	if err != nil {
+		return run.RunId, err
+	}
+	listKey := l.prefix + job_runs_prefix + run.JobId
+	reply := l.client.Send(utils.ToCmdLine("LPUSH", listKey, run.RunId))
+	intReply, ok := reply.(*protocol.IntReply)
+	if ok == false {
+		return run.RunId, errors.New("add run failed")
+	}
+	// drop the oldest records.
+	for i := intReply.Code; i > model.MaxRunsPerJob; i-- {
+		popReply := l.client.Send(utils.ToCmdLine("RPOP", listKey))
+		if bulk, ok := popReply.(*protocol.BulkReply); ok {
+			l.client.Send(utils.ToCmdLine("DEL", l.prefix+run_key_prefix+string(bulk.Arg)))
+		}
+	}
+	return run.RunId, nil
+}
+
+func (l *LocalDb) setRun(run model.RunEntity) error {
+	buffer, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	reply := l.client.Send(utils.ToCmdLine("SET", l.prefix+run_key_prefix+run.RunId, string(buffer)))
+	if status, ok := reply.(*protocol.StatusReply); ok == false || status.IsOKReply() == false {
+		return errors.New("save run failed")
+	}
+	return nil
+}
+
+func (l *LocalDb) UpdateRun(runId string, mp map[string]any) error {
+	if runId == "" {
+		return errors.New("run id cannot be empty")
+	}
+	run, err := l.GetRun(runId)
+	if err != nil {
+		return err
+	}
+	// merge the fields through json, keys of mp are the json names.
+	var origin map[string]any
+	buffer, _ := json.Marshal(run)
+	_ = json.Unmarshal(buffer, &origin)
+	delete(mp, model.RunId)
+	for k, v := range mp {
+		origin[k] = v
+	}
+	buffer, err = json.Marshal(origin)
+	if err != nil {
+		return err
+	}
+	var updated model.RunEntity
+	err = json.Unmarshal(buffer, &updated)
+	if err != nil {
+		return err
+	}
+	return l.setRun(updated)
+}
+
+func (l *LocalDb) GetRun(runId string) (model.RunEntity, error) {
+	var run model.RunEntity
+	reply := l.client.Send(utils.ToCmdLine("GET", l.prefix+run_key_prefix+runId))
+	bulk, ok := reply.(*protocol.BulkReply)
+	if ok == false {
+		return run, errors.New("run is not exists")
+	}
+	err := json.Unmarshal(bulk.Arg, &run)
+	return run, err
+}
+
+func (l *LocalDb) GetRuns(jobId string) ([]model.RunEntity, error) {
+	res := make([]model.RunEntity, 0)
+	reply := l.client.Send(utils.ToCmdLine("LRANGE", l.prefix+job_runs_prefix+jobId, "0", "-1"))
+	ids, ok := reply.(*protocol.MultiBulkReply)
+	if ok == false {
+		return res, nil
+	}
+	for _, id := range ids.Args {
+		run, err := l.GetRun(string(id))
+		if err != nil {
+			continue
+		}
+		res = append(res, run)
+	}
+	return res, nil
+}
+
+func (l *LocalDb) SearchRuns(jobId string, keyword string) ([]model.RunEntity, error) {
+	runs, err := l.GetRuns(jobId)
+	if err != nil {
+		return runs, err
+	}
+	res := make([]model.RunEntity, 0)
+	for _, run := range runs {
+		if model.RunContains(run, keyword) {
+			res = append(res, run)
+		}
+	}
+	return res, nil
+}
+
+func (l *LocalDb) removeRuns(jobId string) {
+	listKey := l.prefix + job_runs_prefix + jobId
+	reply := l.client.Send(utils.ToCmdLine("LRANGE", listKey, "0", "-1"))
+	if ids, ok := reply.(*protocol.MultiBulkReply); ok {
+		for _, id := range ids.Args {
+			l.client.Send(utils.ToCmdLine("DEL", l.prefix+run_key_prefix+string(id)))
+		}
+	}
+	l.client.Send(utils.ToCmdLine("DEL", listKey))
+}
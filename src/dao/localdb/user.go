@@ -0,0 +1,113 @@
+package localdb
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"traitor/dao/model"
+	"traitor/db/protocol"
+	utils "traitor/db/util"
+)
+
+const (
+	user_key_prefix   = "user_"
+	user_keys_set     = "user_keys_set"
+	token_key_prefix  = "token_"
+	token_keys_set    = "token_keys_set"
+	token_hash_prefix = "token_hash_" // hash of the secret -> token id.
+)
+
+// users and tokens are stored as json, see saveJson.
+func (l *LocalDb) AddUser(u model.UserEntity) error {
+	if _, err := l.GetUser(u.Name); err == nil {
+		return errors.New("user already exists")
+	}
+	return l.saveJson(user_keys_set, user_key_prefix, u.Name, u)
+}
+
+func (l *LocalDb) UpdateUser(u model.UserEntity) error {
+	if _, err := l.GetUser(u.Name); err != nil {
+		return err
+	}
+	return l.saveJson(user_keys_set, user_key_prefix, u.Name, u)
+}
+
+// RemoveUser remove the user and its tokens.
+func (l *LocalDb) RemoveUser(name string) error {
+	err := l.removeJson(user_keys_set, user_key_prefix, name)
+	if err != nil {
+		return err
+	}
+	tokens, _ := l.GetTokens(name)
+	for _, t := range tokens {
+		_ = l.RemoveToken(t.TokenId)
+	}
+	return nil
+}
+
+func (l *LocalDb) GetUser(name string) (model.UserEntity, error) {
+	var u model.UserEntity
+	err := l.loadJson(user_key_prefix+name, &u)
+	if err != nil {
+		return u, errors.New("user is not exists")
+	}
+	return u, nil
+}
+
+func (l *LocalDb) GetUsers() ([]model.UserEntity, error) {
+	res := make([]model.UserEntity, 0)
+	for _, name := range l.members(user_keys_set) {
+		if u, err := l.GetUser(name); err == nil {
+			res = append(res, u)
+		}
+	}
+	return res, nil
+}
+
+func (l *LocalDb) AddToken(t model.TokenEntity) (string, error) {
+	t.TokenId = uuid.NewString()
+	err := l.saveJson(token_keys_set, token_key_prefix, t.TokenId, t)
+	if err != nil {
+		return t.TokenId, err
+	}
+	reply := l.client.Send(utils.ToCmdLine("SET", token_hash_prefix+t.Hash, t.TokenId))
+	if status, ok := reply.(*protocol.StatusReply); ok == false || status.IsOKReply() == false {
+		return t.TokenId, errors.New("save failed")
+	}
+	return t.TokenId, nil
+}
+
+func (l *LocalDb) RemoveToken(tokenId string) error {
+	t, err := l.GetToken(tokenId)
+	if err != nil {
+		return err
+	}
+	l.client.Send(utils.ToCmdLine("DEL", token_hash_prefix+t.Hash))
+	return l.removeJson(token_keys_set, token_key_prefix, tokenId)
+}
+
+func (l *LocalDb) GetToken(tokenId string) (model.TokenEntity, error) {
+	var t model.TokenEntity
+	err := l.loadJson(token_key_prefix+tokenId, &t)
+	if err != nil {
+		return t, errors.New("token is not exists")
+	}
+	return t, nil
+}
+
+func (l *LocalDb) GetTokenByHash(hash string) (model.TokenEntity, error) {
+	bulk, ok := l.client.Send(utils.ToCmdLine("GET", token_hash_prefix+hash)).(*protocol.BulkReply)
+	if ok == false {
+		return model.TokenEntity{}, errors.New("token is not exists")
+	}
+	return l.GetToken(string(bulk.Arg))
+}
+
+func (l *LocalDb) GetTokens(user string) ([]model.TokenEntity, error) {
+	res := make([]model.TokenEntity, 0)
+	for _, id := range l.members(token_keys_set) {
+		if t, err := l.GetToken(id); err == nil && t.User == user {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
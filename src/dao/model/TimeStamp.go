@@ -3,6 +3,8 @@ package model
 import (
 	"database/sql/driver"
 	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"strconv"
 	"time"
 )
@@ -30,6 +32,25 @@ func (ts *TimeStamp) UnmarshalJSON(data []byte) error {
 	return err
 }
 
+// MarshalBSONValue save it as a date, the fields of time.Time are not exported.
+func (ts TimeStamp) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(time.Time(ts))
+}
+
+// UnmarshalBSONValue load a date, anything else like the empty document saved before is the zero time.
+func (ts *TimeStamp) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t != bsontype.DateTime {
+		*ts = TimeStamp{}
+		return nil
+	}
+	var v time.Time
+	if err := (bson.RawValue{Type: t, Value: data}).Unmarshal(&v); err != nil {
+		return err
+	}
+	*ts = TimeStamp(v)
+	return nil
+}
+
 func (ts *TimeStamp) ToString() string {
 	return ts.ToTime().Format("2006-01-02 15:04:05")
 }
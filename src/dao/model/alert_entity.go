@@ -0,0 +1,77 @@
+package model
+
+// channel types, how a notification is sent.
+const (
+	WebhookChannel  = "webhook"  // POST the alert as json.
+	EmailChannel    = "email"    // send a mail through SMTP.
+	SlackChannel    = "slack"    // incoming webhook of slack.
+	DingTalkChannel = "dingtalk" // custom robot of dingtalk.
+	FeishuChannel   = "feishu"   // custom bot of feishu.
+)
+
+// ChannelEntity where the notifications of the alert rules are sent.
+type ChannelEntity struct {
+	ChannelId string            `json:"channelId" bson:"channelId"`
+	Name      string            `json:"name" bson:"name"`
+	Type      string            `json:"type" bson:"type"`
+	Url       string            `json:"url,omitempty" bson:"url,omitempty"`         // the webhook channels.
+	Headers   map[string]string `json:"headers,omitempty" bson:"headers,omitempty"` // extra headers of a generic webhook.
+	Smtp      *SmtpSettings     `json:"smtp,omitempty" bson:"smtp,omitempty"`       // the email channel.
+	// Template the text/template of the message, a default one is used if it's empty.
+	Template string `json:"template,omitempty" bson:"template,omitempty"`
+}
+
+// SmtpSettings the server and recipients of an email channel.
+type SmtpSettings struct {
+	Host     string   `json:"host" bson:"host"`
+	Port     int      `json:"port" bson:"port"`
+	Username string   `json:"username,omitempty" bson:"username,omitempty"`
+	Password string   `json:"password,omitempty" bson:"password,omitempty"`
+	From     string   `json:"from" bson:"from"`
+	To       []string `json:"to" bson:"to"`
+}
+
+// rule triggers.
+const (
+	TriggerFailure     = "failure"     // every failed run.
+	TriggerConsecutive = "consecutive" // the Threshold-th failure in a row.
+	TriggerTimeout     = "timeout"     // a run still running after Timeout seconds.
+	TriggerRecovery    = "recovery"    // the first success after a failure.
+)
+
+// AlertRuleEntity when to notify the channels.
+type AlertRuleEntity struct {
+	RuleId string `json:"ruleId" bson:"ruleId"`
+	Name   string `json:"name" bson:"name"`
+	// JobIds and Groups the jobs watched by the rule, by id or by group, all jobs if both are empty.
+	JobIds    []string `json:"jobIds,omitempty" bson:"jobIds,omitempty"`
+	Groups    []string `json:"groups,omitempty" bson:"groups,omitempty"`
+	Trigger   string   `json:"trigger" bson:"trigger"`
+	Threshold int      `json:"threshold,omitempty" bson:"threshold,omitempty"` // failures in a row of consecutive.
+	Timeout   int64    `json:"timeout,omitempty" bson:"timeout,omitempty"`     // seconds of timeout.
+	Channels  []string `json:"channels" bson:"channels"`
+	Disabled  bool     `json:"disabled,omitempty" bson:"disabled,omitempty"`
+}
+
+const (
+	ChannelId = "channelId"
+	RuleId    = "ruleId"
+)
+
+// Watches whether the rule applies to the job.
+func (r *AlertRuleEntity) Watches(j *JobEntity) bool {
+	if len(r.JobIds) == 0 && len(r.Groups) == 0 {
+		return true
+	}
+	for _, id := range r.JobIds {
+		if id == j.JobId {
+			return true
+		}
+	}
+	for _, g := range r.Groups {
+		if j.Group != "" && g == j.Group {
+			return true
+		}
+	}
+	return false
+}
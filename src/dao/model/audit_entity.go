@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// audit actions.
+const (
+	AuditCreate  = "job.create"
+	AuditUpdate  = "job.update"
+	AuditScript  = "job.script"
+	AuditRemove  = "job.remove"
+	AuditEnable  = "job.enable"
+	AuditDisable = "job.disable"
+	AuditRun     = "job.run" // created and enabled at once.
+	AuditImport  = "job.import"
+	AuditSync    = "job.sync" // created, updated or released by the sync of the manifests.
+)
+
+// AuditEntity who changed a job and how, the entries are never updated or removed.
+type AuditEntity struct {
+	AuditId string    `json:"auditId" bson:"auditId"`
+	Time    time.Time `json:"time" bson:"time"`
+	Actor   string    `json:"actor" bson:"actor"`
+	Ip      string    `json:"ip" bson:"ip"`
+	Action  string    `json:"action" bson:"action"`
+	JobId   string    `json:"jobId" bson:"jobId"`
+	Changes []Change  `json:"changes,omitempty" bson:"changes,omitempty"`
+}
+
+// Change a field of the job, Before is nil for a created job and After is nil for a removed one.
+type Change struct {
+	Field  string `json:"field" bson:"field"`
+	Before any    `json:"before,omitempty" bson:"before,omitempty"`
+	After  any    `json:"after,omitempty" bson:"after,omitempty"`
+}
+
+// AuditFilter the empty fields match any entry.
+type AuditFilter struct {
+	JobId  string
+	Actor  string
+	Action string
+	Since  *time.Time
+	Until  *time.Time
+	Limit  int
+}
+
+const (
+	AuditTime   = "time"
+	AuditActor  = "actor"
+	AuditAction = "action"
+)
+
+// Match whether the entry passes the filter, the limit is not applied.
+func (f *AuditFilter) Match(a *AuditEntity) bool {
+	return (f.JobId == "" || a.JobId == f.JobId) &&
+		(f.Actor == "" || a.Actor == f.Actor) &&
+		(f.Action == "" || a.Action == f.Action) &&
+		(f.Since == nil || a.Time.Before(*f.Since) == false) &&
+		(f.Until == nil || a.Time.Before(*f.Until))
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// ClusterInfo the nodes of the cluster seen by a node.
+type ClusterInfo struct {
+	Mode    string     `json:"mode"`
+	Cluster string     `json:"cluster"`
+	Nodes   []NodeInfo `json:"nodes"`
+}
+
+// NodeInfo a live node, Jobs is the count of runnable jobs it owns under the current ring.
+type NodeInfo struct {
+	NodeId        string    `json:"nodeId"`
+	LastHeartbeat time.Time `json:"lastHeartbeat"`
+	Jobs          int       `json:"jobs"`
+	Self          bool      `json:"self"`
+}
@@ -13,16 +13,53 @@ const (
 	Stop     = 0
 )
 
+// task types, what the job actually runs.
+const (
+	ScriptTask  = 0
+	CommandTask = 1
+	HttpTask    = 2
+)
+
 type JobEntity struct {
-	Name         string     `json:"name,omitempty" bson:"name,omitempty"  structs:"name,omitempty"`
-	JobId        string     `json:"jobId,omitempty" bson:"jobId,omitempty"  structs:"jobId,omitempty"`
-	Cron         string     `json:"cron,omitempty" bson:"cron,omitempty"  structs:"cron,omitempty"`
-	Description  string     `json:"description" bson:"description,omitempty" structs:"description,omitempty"`
-	LastExecTime *time.Time `json:"lastExecTime,omitempty" bson:"lastExecTime,omitempty" structs:"lastExecTime,omitempty"`
-	ExecAt       *TimeStamp `json:"execAt,omitempty" bson:"execAt,omitempty" structs:"execAt,omitempty"`
-	ExecType     uint8      `json:"execType" bson:"execType" structs:"execType"`
-	State        uint8      `json:"state" bson:"state" structs:"state"`
-	Script       string     `json:"script" bson:"script" structs:"script,omitempty"`
+	Name         string       `json:"name,omitempty" bson:"name,omitempty"  structs:"name,omitempty"`
+	JobId        string       `json:"jobId,omitempty" bson:"jobId,omitempty"  structs:"jobId,omitempty"`
+	Cron         string       `json:"cron,omitempty" bson:"cron,omitempty"  structs:"cron,omitempty"`
+	Description  string       `json:"description" bson:"description,omitempty" structs:"description,omitempty"`
+	LastExecTime *time.Time   `json:"lastExecTime,omitempty" bson:"lastExecTime,omitempty" structs:"lastExecTime,omitempty"`
+	ExecAt       *TimeStamp   `json:"execAt,omitempty" bson:"execAt,omitempty" structs:"execAt,omitempty"`
+	ExecType     uint8        `json:"execType" bson:"execType" structs:"execType"`
+	State        uint8        `json:"state" bson:"state" structs:"state"`
+	Script       string       `json:"script" bson:"script" structs:"script,omitempty"`
+	Revision     int64        `json:"revision" bson:"revision" structs:"revision"` // changed with every script update.
+	TaskType     uint8        `json:"taskType" bson:"taskType" structs:"taskType"`
+	Command      *Command     `json:"command,omitempty" bson:"command,omitempty" structs:"command,omitempty,omitnested"`
+	Http         *HttpRequest `json:"http,omitempty" bson:"http,omitempty" structs:"http,omitempty,omitnested"`
+	Group        string       `json:"group,omitempty" bson:"group,omitempty" structs:"group,omitempty"` // a label to find the job by.
+	// Params the global `params` of the script.
+	Params map[string]any `json:"params,omitempty" bson:"params,omitempty" structs:"params,omitempty"`
+	// ManagedBy the manifest the job is synced from, the API doesn't change a managed job.
+	ManagedBy string `json:"managedBy,omitempty" bson:"managedBy,omitempty" structs:"managedBy,omitempty"`
+	// Namespace filled by the dao of the namespace, it's where the job is stored and not a field of it.
+	Namespace string `json:"namespace,omitempty" bson:"-" structs:"-"`
+}
+
+// Command the settings of a COMMAND job.
+type Command struct {
+	Args    []string `json:"args" bson:"args" yaml:"args"`
+	Dir     string   `json:"dir,omitempty" bson:"dir,omitempty" yaml:"dir,omitempty"`
+	Env     []string `json:"env,omitempty" bson:"env,omitempty" yaml:"env,omitempty"`             // KEY=VALUE
+	Timeout int64    `json:"timeout,omitempty" bson:"timeout,omitempty" yaml:"timeout,omitempty"` // seconds, 0 for no limit.
+}
+
+// HttpRequest the settings of a HTTP job.
+type HttpRequest struct {
+	Method  string            `json:"method,omitempty" bson:"method,omitempty" yaml:"method,omitempty"` // default GET.
+	Url     string            `json:"url" bson:"url" yaml:"url"`
+	Headers map[string]string `json:"headers,omitempty" bson:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string            `json:"body,omitempty" bson:"body,omitempty" yaml:"body,omitempty"`
+	// ExpectStatus the status codes treated as success, default 2xx.
+	ExpectStatus []int `json:"expectStatus,omitempty" bson:"expectStatus,omitempty" yaml:"expectStatus,omitempty"`
+	Timeout      int64 `json:"timeout,omitempty" bson:"timeout,omitempty" yaml:"timeout,omitempty"` // seconds, default 30.
 }
 
 const (
@@ -35,6 +72,13 @@ const (
 	ExecType     = "execType"
 	ExecAt       = "execAt"
 	State        = "state"
+	TaskType     = "taskType"
+	CommandField = "command"
+	HttpField    = "http"
+	Revision     = "revision"
+	Group        = "group"
+	Params       = "params"
+	ManagedBy    = "managedBy"
 )
 
 type ScriptEntity struct {
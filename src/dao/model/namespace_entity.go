@@ -0,0 +1,29 @@
+package model
+
+import (
+	"regexp"
+	"time"
+)
+
+// DefaultNamespace the namespace of the jobs created before namespaces, it always exists.
+const DefaultNamespace = "default"
+
+// NamespaceEntity a group of jobs kept apart from the others, the zero quotas are no limit.
+type NamespaceEntity struct {
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	MaxJobs     int    `json:"maxJobs,omitempty" bson:"maxJobs,omitempty"`
+	// MaxConcurrentRuns the runs of the namespace in flight at once, across the nodes.
+	MaxConcurrentRuns int       `json:"maxConcurrentRuns,omitempty" bson:"maxConcurrentRuns,omitempty"`
+	CreatedAt         time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+const NamespaceName = "name"
+
+// the names are part of the storage keys and database names, so they are kept plain.
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,30}[a-z0-9])?$`)
+
+// ValidNamespace whether the name could be used for a namespace.
+func ValidNamespace(name string) bool {
+	return namespacePattern.MatchString(name)
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// run states.
+const (
+	RunRunning   = 0
+	RunSuccess   = 1
+	RunFailed    = 2
+	RunCancelled = 3
+)
+
+// RunEntity the record of one execution of a job.
+type RunEntity struct {
+	RunId    string     `json:"runId" bson:"runId"`
+	JobId    string     `json:"jobId" bson:"jobId"`
+	NodeId   string     `json:"nodeId,omitempty" bson:"nodeId,omitempty"`
+	StartAt  *time.Time `json:"startAt,omitempty" bson:"startAt,omitempty"`
+	EndAt    *time.Time `json:"endAt,omitempty" bson:"endAt,omitempty"`
+	State    uint8      `json:"state" bson:"state"`
+	Error    string     `json:"error,omitempty" bson:"error,omitempty"`
+	ExitCode int        `json:"exitCode" bson:"exitCode"`
+	Stdout   string     `json:"stdout,omitempty" bson:"stdout,omitempty"`
+	Stderr   string     `json:"stderr,omitempty" bson:"stderr,omitempty"`
+	// StatusCode and Response are the result of a HTTP job.
+	StatusCode int    `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	Response   string `json:"response,omitempty" bson:"response,omitempty"`
+	// Output the console output of a script job.
+	Output string `json:"output,omitempty" bson:"output,omitempty"`
+	// Namespace of the job, the runs in flight of all the namespaces are listed together.
+	Namespace string `json:"namespace,omitempty" bson:"namespace,omitempty"`
+}
+
+const (
+	RunId      = "runId"
+	RunState   = "state"
+	StartAt    = "startAt"
+	EndAt      = "endAt"
+	RunError   = "error"
+	ExitCode   = "exitCode"
+	Stdout     = "stdout"
+	Stderr     = "stderr"
+	StatusCode = "statusCode"
+	Response   = "response"
+	Output     = "output"
+)
+
+// MaxRunsPerJob how many run records are kept for a job.
+const MaxRunsPerJob = 100
+
+// RunSearchFields the fields matched by the run search.
+var RunSearchFields = []string{Output, RunError, Stdout, Stderr, Response}
+
+// RunContains whether the output or error of the run contains the keyword.
+func RunContains(run RunEntity, keyword string) bool {
+	for _, text := range []string{run.Output, run.Error, run.Stdout, run.Stderr, run.Response} {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// UserEntity who may call the API, the role decides what it may do.
+type UserEntity struct {
+	Name      string    `json:"name" bson:"name"`
+	Role      string    `json:"role" bson:"role"`
+	Disabled  bool      `json:"disabled,omitempty" bson:"disabled,omitempty"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	// Namespaces the user could access, empty for all of them.
+	Namespaces []string `json:"namespaces,omitempty" bson:"namespaces,omitempty"`
+}
+
+// TokenEntity an API token of a user, only the hash of the secret is stored.
+type TokenEntity struct {
+	TokenId   string     `json:"tokenId" bson:"tokenId"`
+	User      string     `json:"user" bson:"user"`
+	Name      string     `json:"name" bson:"name"` // what the token is used for.
+	Hash      string     `json:"hash,omitempty" bson:"hash"`
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+}
+
+const (
+	UserName = "name"
+	TokenId  = "tokenId"
+	User     = "user"
+	Hash     = "hash"
+)
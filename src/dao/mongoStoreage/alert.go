@@ -0,0 +1,98 @@
+package mongoStoreage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"traitor/dao/model"
+)
+
+const (
+	alertChannels = "alert_channels"
+	alertRules    = "alert_rules"
+)
+
+func (m *MongoDao) AddChannel(ch model.ChannelEntity) (string, error) {
+	ch.ChannelId = uuid.NewString()
+	_, err := m.c.Database(m.nsDatabase).Collection(alertChannels).InsertOne(context.TODO(), ch)
+	return ch.ChannelId, err
+}
+
+func (m *MongoDao) UpdateChannel(ch model.ChannelEntity) error {
+	coll := m.c.Database(m.nsDatabase).Collection(alertChannels)
+	res, err := coll.ReplaceOne(context.TODO(), bson.M{model.ChannelId: ch.ChannelId}, ch)
+	if err == nil && res.MatchedCount == 0 {
+		return errors.New("channel is not exists")
+	}
+	return err
+}
+
+func (m *MongoDao) RemoveChannel(channelId string) error {
+	coll := m.c.Database(m.nsDatabase).Collection(alertChannels)
+	res, err := coll.DeleteOne(context.TODO(), bson.M{model.ChannelId: channelId})
+	if err == nil && res.DeletedCount == 0 {
+		return errors.New("remove failed")
+	}
+	return err
+}
+
+func (m *MongoDao) GetChannel(channelId string) (model.ChannelEntity, error) {
+	coll := m.c.Database(m.nsDatabase).Collection(alertChannels)
+	var res model.ChannelEntity
+	err := coll.FindOne(context.TODO(), bson.M{model.ChannelId: channelId}).Decode(&res)
+	return res, err
+}
+
+func (m *MongoDao) GetChannels() ([]model.ChannelEntity, error) {
+	coll := m.c.Database(m.nsDatabase).Collection(alertChannels)
+	res := make([]model.ChannelEntity, 0)
+	cursor, err := coll.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return res, err
+	}
+	err = cursor.All(context.TODO(), &res)
+	return res, err
+}
+
+func (m *MongoDao) AddAlertRule(rule model.AlertRuleEntity) (string, error) {
+	rule.RuleId = uuid.NewString()
+	_, err := m.c.Database(m.nsDatabase).Collection(alertRules).InsertOne(context.TODO(), rule)
+	return rule.RuleId, err
+}
+
+func (m *MongoDao) UpdateAlertRule(rule model.AlertRuleEntity) error {
+	coll := m.c.Database(m.nsDatabase).Collection(alertRules)
+	res, err := coll.ReplaceOne(context.TODO(), bson.M{model.RuleId: rule.RuleId}, rule)
+	if err == nil && res.MatchedCount == 0 {
+		return errors.New("alert rule is not exists")
+	}
+	return err
+}
+
+func (m *MongoDao) RemoveAlertRule(ruleId string) error {
+	coll := m.c.Database(m.nsDatabase).Collection(alertRules)
+	res, err := coll.DeleteOne(context.TODO(), bson.M{model.RuleId: ruleId})
+	if err == nil && res.DeletedCount == 0 {
+		return errors.New("remove failed")
+	}
+	return err
+}
+
+func (m *MongoDao) GetAlertRule(ruleId string) (model.AlertRuleEntity, error) {
+	coll := m.c.Database(m.nsDatabase).Collection(alertRules)
+	var res model.AlertRuleEntity
+	err := coll.FindOne(context.TODO(), bson.M{model.RuleId: ruleId}).Decode(&res)
+	return res, err
+}
+
+func (m *MongoDao) GetAlertRules() ([]model.AlertRuleEntity, error) {
+	coll := m.c.Database(m.nsDatabase).Collection(alertRules)
+	res := make([]model.AlertRuleEntity, 0)
+	cursor, err := coll.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return res, err
+	}
+	err = cursor.All(context.TODO(), &res)
+	return res, err
+}
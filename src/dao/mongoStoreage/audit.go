@@ -0,0 +1,52 @@
+package mongoStoreage
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"traitor/dao/model"
+)
+
+const auditLog = "audit_log"
+
+func (m *MongoDao) AddAudit(a model.AuditEntity) error {
+	a.AuditId = uuid.NewString()
+	_, err := m.c.Database(m.nsDatabase).Collection(auditLog).InsertOne(context.TODO(), a)
+	return err
+}
+
+func (m *MongoDao) GetAudits(f model.AuditFilter) ([]model.AuditEntity, error) {
+	coll := m.c.Database(m.nsDatabase).Collection(auditLog)
+	res := make([]model.AuditEntity, 0)
+	filter := bson.M{}
+	if f.JobId != "" {
+		filter[model.JobId] = f.JobId
+	}
+	if f.Actor != "" {
+		filter[model.AuditActor] = f.Actor
+	}
+	if f.Action != "" {
+		filter[model.AuditAction] = f.Action
+	}
+	period := bson.M{}
+	if f.Since != nil {
+		period["$gte"] = *f.Since
+	}
+	if f.Until != nil {
+		period["$lt"] = *f.Until
+	}
+	if len(period) > 0 {
+		filter[model.AuditTime] = period
+	}
+	opt := options.Find().SetSort(bson.M{model.AuditTime: -1})
+	if f.Limit > 0 {
+		opt.SetLimit(int64(f.Limit))
+	}
+	cursor, err := coll.Find(context.TODO(), filter, opt)
+	if err != nil {
+		return res, err
+	}
+	err = cursor.All(context.TODO(), &res)
+	return res, err
+}
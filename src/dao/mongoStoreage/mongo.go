@@ -8,17 +8,41 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
 	"traitor/dao/model"
 	"traitor/logger"
 )
 
 const (
-	jobInfos = "job_infos"
+	jobInfos   = "job_infos"
+	runRecords = "run_records"
 )
 
+// jobFields the fields of a job without its script.
+var jobFields = bson.M{
+	model.JobId:        1,
+	model.Cron:         1,
+	model.Name:         1,
+	model.LastExecTime: 1,
+	model.State:        1,
+	model.ExecType:     1,
+	model.Description:  1,
+	model.ExecAt:       1,
+	model.TaskType:     1,
+	model.CommandField: 1,
+	model.HttpField:    1,
+	model.Revision:     1,
+	model.Group:        1,
+	model.Params:       1,
+	model.ManagedBy:    1,
+}
+
 type MongoDao struct {
 	c            *mongo.Client
 	databaseName string
+	// the jobs, runs, alerts and audit entries of the namespace are kept in their own database.
+	namespace  string
+	nsDatabase string
 }
 
 func CreateMongoDao(uri string, cluster string) *MongoDao {
@@ -35,16 +59,27 @@ func CreateMongoDao(uri string, cluster string) *MongoDao {
 	var res = &MongoDao{
 		c:            client,
 		databaseName: databaseName,
+		namespace:    model.DefaultNamespace,
+		nsDatabase:   databaseName,
 	}
 	return res
 }
+
+// Namespace the dao keeping the data of the namespace, users, tokens and namespaces are shared.
+func (m *MongoDao) Namespace(ns string) *MongoDao {
+	if ns == "" || ns == model.DefaultNamespace { // the database from before namespaces.
+		return &MongoDao{c: m.c, databaseName: m.databaseName, namespace: model.DefaultNamespace, nsDatabase: m.databaseName}
+	}
+	return &MongoDao{c: m.c, databaseName: m.databaseName, namespace: ns, nsDatabase: m.databaseName + "_ns_" + ns}
+}
+func (m *MongoDao) Ping() error {
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*3)
+	defer cancel()
+	return m.c.Ping(ctx, nil)
+}
 func (m *MongoDao) GetJobInfos() ([]model.JobEntity, error) {
-	coll := m.c.Database(m.databaseName).Collection(jobInfos)
-	opt := options.Find().SetProjection(bson.M{
-		"jobId":   1,
-		"cron":    1,
-		"jobType": 1,
-	})
+	coll := m.c.Database(m.nsDatabase).Collection(jobInfos)
+	opt := options.Find().SetProjection(jobFields)
 	res := make([]model.JobEntity, 0)
 
 	cursor, err := coll.Find(context.TODO(), bson.M{}, opt)
@@ -57,17 +92,15 @@ func (m *MongoDao) GetJobInfos() ([]model.JobEntity, error) {
 		logger.Error(err.Error())
 		return res, err
 	}
-
+	for i := range res {
+		res[i].Namespace = m.namespace
+	}
 	return res, nil
 }
 func (m *MongoDao) GetRunnableJobs() ([]model.JobEntity, error) {
-	coll := m.c.Database(m.databaseName).Collection(jobInfos)
+	coll := m.c.Database(m.nsDatabase).Collection(jobInfos)
 	filter := bson.M{"state": model.Runnable}
-	opt := options.Find().SetProjection(bson.M{
-		"jobId":   1,
-		"cron":    1,
-		"jobType": 1,
-	})
+	opt := options.Find().SetProjection(jobFields)
 	res := make([]model.JobEntity, 0)
 
 	cursor, err := coll.Find(context.TODO(), filter, opt)
@@ -80,32 +113,26 @@ func (m *MongoDao) GetRunnableJobs() ([]model.JobEntity, error) {
 		logger.Error(err.Error())
 		return res, err
 	}
-
+	for i := range res {
+		res[i].Namespace = m.namespace
+	}
 	return res, nil
 }
 func (m *MongoDao) GetJobInfo(jobId string) (model.JobEntity, error) {
-	coll := m.c.Database(m.databaseName).Collection(jobInfos)
+	coll := m.c.Database(m.nsDatabase).Collection(jobInfos)
 	filter := bson.M{"jobId": jobId}
-	opt := options.FindOne().SetProjection(bson.M{
-		model.JobId:        1,
-		model.Cron:         1,
-		model.Name:         1,
-		model.LastExecTime: 1,
-		model.State:        1,
-		model.ExecType:     1,
-		model.Description:  1,
-		model.ExecAt:       1,
-	})
+	opt := options.FindOne().SetProjection(jobFields)
 	var res model.JobEntity
 	err := coll.FindOne(context.TODO(), filter, opt).Decode(&res)
 	if err != nil {
 		return res, err
 	}
+	res.Namespace = m.namespace
 	return res, err
 }
 
 func (m *MongoDao) GetJobScript(jobId string) (model.ScriptEntity, error) {
-	coll := m.c.Database(m.databaseName).Collection(jobInfos)
+	coll := m.c.Database(m.nsDatabase).Collection(jobInfos)
 	filter := bson.M{"jobId": jobId}
 	opt := options.FindOne().SetProjection(bson.M{
 		"jobId":  1,
@@ -123,7 +150,7 @@ func (m *MongoDao) AddJob(job model.JobEntity) (string, error) {
 	if job.JobId == "" {
 		job.JobId = uuid.New().String()
 	}
-	coll := m.c.Database(m.databaseName).Collection(jobInfos)
+	coll := m.c.Database(m.nsDatabase).Collection(jobInfos)
 	_, err := coll.InsertOne(context.TODO(), job)
 	if err != nil {
 		return job.JobId, err
@@ -135,11 +162,11 @@ func (m *MongoDao) UpdateJob(jobId string, mp map[string]any) error {
 	if jobId == "" {
 		return errors.New("job id cannot be empty")
 	}
-	coll := m.c.Database(m.databaseName).Collection(jobInfos)
+	coll := m.c.Database(m.nsDatabase).Collection(jobInfos)
 	filter := bson.M{model.JobId: jobId}
 	delete(mp, model.JobId)
 
-	res := coll.FindOneAndUpdate(context.TODO(), filter, mp)
+	res := coll.FindOneAndUpdate(context.TODO(), filter, bson.M{"$set": mp})
 	if res.Err() != nil {
 		return res.Err()
 	}
@@ -147,11 +174,11 @@ func (m *MongoDao) UpdateJob(jobId string, mp map[string]any) error {
 }
 
 func (m *MongoDao) EditJobScript(jobId string, script string) error {
-	coll := m.c.Database(m.databaseName).Collection(jobInfos)
+	coll := m.c.Database(m.nsDatabase).Collection(jobInfos)
 	filter := bson.M{model.JobId: jobId}
-	update := bson.M{
+	update := bson.M{"$set": bson.M{
 		model.Script: script,
-	}
+	}}
 	res := coll.FindOneAndUpdate(context.TODO(), filter, update)
 	if res.Err() != nil {
 		return res.Err()
@@ -160,12 +187,16 @@ func (m *MongoDao) EditJobScript(jobId string, script string) error {
 }
 
 func (m *MongoDao) RemoveJob(jobId string) error {
-	coll := m.c.Database(m.databaseName).Collection(jobInfos)
+	coll := m.c.Database(m.nsDatabase).Collection(jobInfos)
 	filter := bson.M{"jobId": jobId}
 	_, err := coll.DeleteOne(context.TODO(), filter)
 	if err != nil {
 		return err
 	}
+	_, err = m.c.Database(m.nsDatabase).Collection(runRecords).DeleteMany(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,45 @@
+package mongoStoreage
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+	"time"
+	"traitor/dao/model"
+)
+
+// a job saved and loaded through the projection of jobFields keeps every field but its script.
+func Test_jobFields(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	execAt := model.TimeStamp(now)
+	job := model.JobEntity{
+		Name: "job", JobId: "id", Cron: "0 0 * * * * *", Description: "d", LastExecTime: &now, ExecAt: &execAt,
+		ExecType: model.TimingExecute, State: model.Runnable, Script: "// script", Revision: 2, TaskType: model.HttpTask,
+		Command: &model.Command{Args: []string{"echo"}}, Http: &model.HttpRequest{Url: "http://localhost"},
+		Group: "g", Params: map[string]any{"day": "monday"}, ManagedBy: "jobs/job.job.yaml",
+	}
+	buffer, err := bson.Marshal(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc bson.M
+	if err = bson.Unmarshal(buffer, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for k := range doc {
+		if _, ok := jobFields[k]; ok == false {
+			delete(doc, k)
+		}
+	}
+	if buffer, err = bson.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+	var got model.JobEntity
+	if err = bson.Unmarshal(buffer, &got); err != nil {
+		t.Fatal(err)
+	}
+	job.Script = ""
+	if reflect.DeepEqual(got, job) == false {
+		t.Errorf("projected job = %+v, want %+v", got, job)
+	}
+}
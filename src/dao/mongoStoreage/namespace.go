@@ -0,0 +1,74 @@
+package mongoStoreage
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"traitor/dao/model"
+)
+
+const namespaces = "namespaces"
+
+func (m *MongoDao) AddNamespace(n model.NamespaceEntity) error {
+	if _, err := m.GetNamespace(n.Name); err == nil {
+		return errors.New("namespace already exists")
+	}
+	_, err := m.c.Database(m.databaseName).Collection(namespaces).InsertOne(context.TODO(), n)
+	return err
+}
+
+// UpdateNamespace the default namespace is saved the first time it's updated.
+func (m *MongoDao) UpdateNamespace(n model.NamespaceEntity) error {
+	if _, err := m.GetNamespace(n.Name); err != nil {
+		return err
+	}
+	coll := m.c.Database(m.databaseName).Collection(namespaces)
+	_, err := coll.ReplaceOne(context.TODO(), bson.M{model.NamespaceName: n.Name}, n, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (m *MongoDao) RemoveNamespace(name string) error {
+	if name == model.DefaultNamespace {
+		return errors.New("the default namespace cannot be removed")
+	}
+	coll := m.c.Database(m.databaseName).Collection(namespaces)
+	res, err := coll.DeleteOne(context.TODO(), bson.M{model.NamespaceName: name})
+	if err == nil && res.DeletedCount == 0 {
+		return errors.New("remove failed")
+	}
+	return err
+}
+
+// GetNamespace the default namespace exists even if it was never saved.
+func (m *MongoDao) GetNamespace(name string) (model.NamespaceEntity, error) {
+	coll := m.c.Database(m.databaseName).Collection(namespaces)
+	var res model.NamespaceEntity
+	err := coll.FindOne(context.TODO(), bson.M{model.NamespaceName: name}).Decode(&res)
+	if err != nil && name == model.DefaultNamespace {
+		return model.NamespaceEntity{Name: model.DefaultNamespace}, nil
+	}
+	return res, err
+}
+
+// GetNamespaces the default namespace first.
+func (m *MongoDao) GetNamespaces() ([]model.NamespaceEntity, error) {
+	coll := m.c.Database(m.databaseName).Collection(namespaces)
+	saved := make([]model.NamespaceEntity, 0)
+	cursor, err := coll.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return saved, err
+	}
+	err = cursor.All(context.TODO(), &saved)
+	if err != nil {
+		return saved, err
+	}
+	def, _ := m.GetNamespace(model.DefaultNamespace)
+	res := []model.NamespaceEntity{def}
+	for _, n := range saved {
+		if n.Name != model.DefaultNamespace {
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,83 @@
+package mongoStoreage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
+	"traitor/dao/model"
+)
+
+func (m *MongoDao) AddRun(run model.RunEntity) (string, error) {
+	if run.RunId == "" {
+		run.RunId = uuid.NewString()
+	}
+	coll := m.c.Database(m.nsDatabase).Collection(runRecords)
+	_, err := coll.InsertOne(context.TODO(), run)
+	if err != nil {
+		return run.RunId, err
+	}
+	// drop the oldest records.
+	opt := options.Find().SetSort(bson.M{model.StartAt: -1}).SetSkip(model.MaxRunsPerJob).
+		SetProjection(bson.M{model.RunId: 1})
+	cursor, err := coll.Find(context.TODO(), bson.M{model.JobId: run.JobId}, opt)
+	if err != nil {
+		return run.RunId, nil
+	}
+	var old []model.RunEntity
+	if cursor.All(context.TODO(), &old) == nil && len(old) > 0 {
+		ids := make([]string, len(old))
+		for i, r := range old {
+			ids[i] = r.RunId
+		}
+		_, _ = coll.DeleteMany(context.TODO(), bson.M{model.RunId: bson.M{"$in": ids}})
+	}
+	return run.RunId, nil
+}
+
+func (m *MongoDao) UpdateRun(runId string, mp map[string]any) error {
+	if runId == "" {
+		return errors.New("run id cannot be empty")
+	}
+	coll := m.c.Database(m.nsDatabase).Collection(runRecords)
+	delete(mp, model.RunId)
+	_, err := coll.UpdateOne(context.TODO(), bson.M{model.RunId: runId}, bson.M{"$set": mp})
+	return err
+}
+
+func (m *MongoDao) GetRun(runId string) (model.RunEntity, error) {
+	coll := m.c.Database(m.nsDatabase).Collection(runRecords)
+	var res model.RunEntity
+	err := coll.FindOne(context.TODO(), bson.M{model.RunId: runId}).Decode(&res)
+	return res, err
+}
+
+func (m *MongoDao) GetRuns(jobId string) ([]model.RunEntity, error) {
+	coll := m.c.Database(m.nsDatabase).Collection(runRecords)
+	res := make([]model.RunEntity, 0)
+	opt := options.Find().SetSort(bson.M{model.StartAt: -1}).SetLimit(model.MaxRunsPerJob)
+	cursor, err := coll.Find(context.TODO(), bson.M{model.JobId: jobId}, opt)
+	if err != nil {
+		return res, err
+	}
+	err = cursor.All(context.TODO(), &res)
+	return res, err
+}
+
+func (m *MongoDao) SearchRuns(jobId string, keyword string) ([]model.RunEntity, error) {
+	coll := m.c.Database(m.nsDatabase).Collection(runRecords)
+	res := make([]model.RunEntity, 0)
+	or := make(bson.A, 0, len(model.RunSearchFields))
+	for _, field := range model.RunSearchFields {
+		or = append(or, bson.M{field: bson.M{"$regex": regexp.QuoteMeta(keyword)}})
+	}
+	opt := options.Find().SetSort(bson.M{model.StartAt: -1}).SetLimit(model.MaxRunsPerJob)
+	cursor, err := coll.Find(context.TODO(), bson.M{model.JobId: jobId, "$or": or}, opt)
+	if err != nil {
+		return res, err
+	}
+	err = cursor.All(context.TODO(), &res)
+	return res, err
+}
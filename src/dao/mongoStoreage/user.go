@@ -0,0 +1,103 @@
+package mongoStoreage
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"traitor/dao/model"
+)
+
+const (
+	users  = "users"
+	tokens = "tokens"
+)
+
+func (m *MongoDao) AddUser(u model.UserEntity) error {
+	if _, err := m.GetUser(u.Name); err == nil {
+		return errors.New("user already exists")
+	}
+	_, err := m.c.Database(m.databaseName).Collection(users).InsertOne(context.TODO(), u)
+	return err
+}
+
+func (m *MongoDao) UpdateUser(u model.UserEntity) error {
+	coll := m.c.Database(m.databaseName).Collection(users)
+	res, err := coll.ReplaceOne(context.TODO(), bson.M{model.UserName: u.Name}, u)
+	if err == nil && res.MatchedCount == 0 {
+		return errors.New("user is not exists")
+	}
+	return err
+}
+
+// RemoveUser remove the user and its tokens.
+func (m *MongoDao) RemoveUser(name string) error {
+	coll := m.c.Database(m.databaseName).Collection(users)
+	res, err := coll.DeleteOne(context.TODO(), bson.M{model.UserName: name})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("remove failed")
+	}
+	_, err = m.c.Database(m.databaseName).Collection(tokens).DeleteMany(context.TODO(), bson.M{model.User: name})
+	return err
+}
+
+func (m *MongoDao) GetUser(name string) (model.UserEntity, error) {
+	coll := m.c.Database(m.databaseName).Collection(users)
+	var res model.UserEntity
+	err := coll.FindOne(context.TODO(), bson.M{model.UserName: name}).Decode(&res)
+	return res, err
+}
+
+func (m *MongoDao) GetUsers() ([]model.UserEntity, error) {
+	coll := m.c.Database(m.databaseName).Collection(users)
+	res := make([]model.UserEntity, 0)
+	cursor, err := coll.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return res, err
+	}
+	err = cursor.All(context.TODO(), &res)
+	return res, err
+}
+
+func (m *MongoDao) AddToken(t model.TokenEntity) (string, error) {
+	t.TokenId = uuid.NewString()
+	_, err := m.c.Database(m.databaseName).Collection(tokens).InsertOne(context.TODO(), t)
+	return t.TokenId, err
+}
+
+func (m *MongoDao) RemoveToken(tokenId string) error {
+	coll := m.c.Database(m.databaseName).Collection(tokens)
+	res, err := coll.DeleteOne(context.TODO(), bson.M{model.TokenId: tokenId})
+	if err == nil && res.DeletedCount == 0 {
+		return errors.New("remove failed")
+	}
+	return err
+}
+
+func (m *MongoDao) GetToken(tokenId string) (model.TokenEntity, error) {
+	coll := m.c.Database(m.databaseName).Collection(tokens)
+	var res model.TokenEntity
+	err := coll.FindOne(context.TODO(), bson.M{model.TokenId: tokenId}).Decode(&res)
+	return res, err
+}
+
+func (m *MongoDao) GetTokenByHash(hash string) (model.TokenEntity, error) {
+	coll := m.c.Database(m.databaseName).Collection(tokens)
+	var res model.TokenEntity
+	err := coll.FindOne(context.TODO(), bson.M{model.Hash: hash}).Decode(&res)
+	return res, err
+}
+
+func (m *MongoDao) GetTokens(user string) ([]model.TokenEntity, error) {
+	coll := m.c.Database(m.databaseName).Collection(tokens)
+	res := make([]model.TokenEntity, 0)
+	cursor, err := coll.Find(context.TODO(), bson.M{model.User: user})
+	if err != nil {
+		return res, err
+	}
+	err = cursor.All(context.TODO(), &res)
+	return res, err
+}
@@ -92,6 +92,11 @@ func (handler *Handler) AddAof(dbIndex int, cmdLine CmdLine) {
 	}
 }
 
+// QueueLen the commands waiting to be written
+func (handler *Handler) QueueLen() int {
+	return len(handler.aofChan)
+}
+
 // LoadAof read aof file
 func (handler *Handler) LoadAof(maxBytes int) {
 	// delete aofChan to prevent write again
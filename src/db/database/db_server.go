@@ -122,6 +122,14 @@ func (ds *DbServer) GetDBSize(dbIndex int) (int, int) {
 	return db.data.Len(), db.ttlMap.Len()
 }
 
+// GetAofQueueLen returns the commands waiting to be appended into the aof file
+func (ds *DbServer) GetAofQueueLen() int {
+	if ds.aofHandler == nil {
+		return 0
+	}
+	return ds.aofHandler.QueueLen()
+}
+
 // AfterClientClose does some clean after client close connection
 func (ds *DbServer) AfterClientClose(c redis.Connection) {
 
@@ -28,6 +28,25 @@ type handler struct {
 type ConnectionFactory func() *client.Client
 type Closer func()
 
+// Stats of the started server
+type Stats struct {
+	Keys     int
+	TtlKeys  int
+	AofQueue int
+}
+
+var server atomic.Pointer[database2.DbServer]
+
+// GetStats returns the stats of the last started server
+func GetStats() Stats {
+	ds := server.Load()
+	if ds == nil {
+		return Stats{}
+	}
+	keys, ttlKeys := ds.GetDBSize(0)
+	return Stats{Keys: keys, TtlKeys: ttlKeys, AofQueue: ds.GetAofQueueLen()}
+}
+
 func CreateConnection(h *handler) *client.Client {
 	var cnn = redis.NewConn()
 	var c = client.CreateClient(cnn)
@@ -39,9 +58,11 @@ func (h *handler) close(cnn *redis.Connection) {
 	h.activeConn.Delete(cnn)
 }
 func Startup(ctx context.Context) (ConnectionFactory, Closer) {
+	ds := database2.NewStandaloneServer()
+	server.Store(ds)
 	var h = handler{
 		activeConn:    sync.Map{},
-		db:            database2.NewStandaloneServer(),
+		db:            ds,
 		blockingQueue: make(chan *redis.Connection),
 	}
 	go func() {
@@ -28,6 +28,7 @@ func (ql *QuickList) Add(val any) {
 		page := make([]any, 0, pageSize)
 		page = append(page, val)
 		ql.data.PushBack(page)
+		return
 	}
 
 	backNode := ql.data.Back()
@@ -0,0 +1,17 @@
+package list
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuickList_Add(t *testing.T) {
+	ql := NewQuickList()
+	ql.Add("b")
+	ql.Insert(0, "a")
+	ql.Add("c")
+	want := []any{"a", "b", "c"}
+	if got := ql.Range(0, ql.Len()); reflect.DeepEqual(got, want) == false {
+		t.Errorf("Range() = %v, want %v", got, want)
+	}
+}
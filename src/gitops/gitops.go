@@ -0,0 +1,150 @@
+// Package gitops the job definitions kept in a directory, a manifest file next to the script of each job.
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"traitor/bundle"
+)
+
+// the suffixes of the manifest files, the rest of the directory is ignored.
+var suffixes = []string{".job.yaml", ".job.yml", ".job.json"}
+
+// Manifest a job defined by a file of the directory.
+type Manifest struct {
+	bundle.Job `yaml:",inline"`
+	Namespace  string `json:"namespace,omitempty" yaml:"namespace,omitempty"` // default is the default namespace.
+	// ScriptFile the script of a SCRIPT job relative to the manifest, default is the .js file of the same name.
+	ScriptFile string `json:"scriptFile,omitempty" yaml:"scriptFile,omitempty"`
+	File       string `json:"-" yaml:"-"` // the path of the manifest relative to the directory, with slashes.
+}
+
+// FileError a manifest that could not be read.
+type FileError struct {
+	File string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.File + ": " + e.Err.Error()
+}
+
+// Read the manifests under dir and their scripts, the hidden directories like .git are skipped.
+// the error is of the directory, a file that could not be read is one of the FileErrors.
+func Read(dir string) ([]Manifest, []*FileError, error) {
+	manifests := make([]Manifest, 0)
+	problems := make([]*FileError, 0)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := manifestName(d.Name())
+		if name == "" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		m, err := readManifest(p, name)
+		if err != nil {
+			problems = append(problems, &FileError{File: filepath.ToSlash(rel), Err: err})
+			return nil
+		}
+		m.File = filepath.ToSlash(rel)
+		manifests = append(manifests, m)
+		return nil
+	})
+	return manifests, problems, err
+}
+
+// manifestName the file name without the suffix, empty if it's not a manifest.
+func manifestName(file string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(file, suffix) && len(file) > len(suffix) {
+			return strings.TrimSuffix(file, suffix)
+		}
+	}
+	return ""
+}
+
+func readManifest(p string, name string) (Manifest, error) {
+	var m Manifest
+	buffer, err := os.ReadFile(p)
+	if err != nil {
+		return m, err
+	}
+	if strings.HasSuffix(p, ".json") {
+		err = json.Unmarshal(buffer, &m)
+	} else {
+		err = yaml.UnmarshalStrict(buffer, &m)
+	}
+	if err != nil {
+		return m, err
+	}
+	if m.Id == "" {
+		m.Id = name
+	}
+	if m.Name == "" {
+		m.Name = m.Id
+	}
+	if (m.Task == "" || m.Task == "SCRIPT") && m.Script == "" {
+		file := m.ScriptFile
+		if file == "" {
+			file = name + ".js"
+		}
+		if path.IsAbs(filepath.ToSlash(file)) || strings.HasPrefix(path.Clean(filepath.ToSlash(file)), "..") {
+			return m, fmt.Errorf("the script %s is out of the directory of the manifest", file)
+		}
+		sc, err := os.ReadFile(filepath.Join(filepath.Dir(p), filepath.FromSlash(file)))
+		if err != nil {
+			return m, fmt.Errorf("read the script: %w", err)
+		}
+		m.Script = string(sc)
+	}
+	return m, nil
+}
+
+// Revision the commit checked out in dir, empty if it's not a git checkout.
+func Revision(ctx context.Context, dir string) string {
+	out, err := git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// Pull fast-forward the git checkout in dir to its upstream.
+func Pull(ctx context.Context, dir string) error {
+	_, err := git(ctx, dir, "pull", "--ff-only", "--quiet")
+	return err
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,47 @@
+package gitops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"reports/nightly.job.yaml": "cron: 0 0 2 * * * *\nenabled: true\nparams:\n  day: today\n",
+		"reports/nightly.js":       "console.log(params.day)",
+		"ping.job.json":            `{"id":"ping","namespace":"ops","cron":"0 * * * * * *","task":"HTTP","http":{"url":"http://localhost/"}}`,
+		"escape.job.yaml":          "scriptFile: ../outside.js\n",
+		"unknown.job.yaml":         "schedule: daily\n",
+		".git/hidden.job.yaml":     "cron: bad\n",
+		"README.md":                "not a manifest",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifests, problems, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 2 {
+		t.Fatalf("Read() manifests = %+v", manifests)
+	}
+	ping, nightly := manifests[0], manifests[1]
+	if ping.Id != "ping" || ping.Namespace != "ops" || ping.Http == nil || ping.Script != "" {
+		t.Errorf("ping = %+v", ping)
+	}
+	if nightly.Id != "nightly" || nightly.Name != "nightly" || nightly.File != "reports/nightly.job.yaml" ||
+		nightly.Script != "console.log(params.day)" || nightly.Params["day"] != "today" {
+		t.Errorf("nightly = %+v", nightly)
+	}
+	if len(problems) != 2 || problems[0].File != "escape.job.yaml" || problems[1].File != "unknown.job.yaml" {
+		t.Errorf("Read() problems = %v", problems)
+	}
+}
@@ -1,13 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+	"traitor/config"
+	"traitor/logger"
 	"traitor/server"
+	"traitor/tracing"
 )
 
+// shutdownTimeout how long the requests in flight are waited for on exit.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	gin.SetMode("release")
 	r := gin.Default()
@@ -17,13 +30,82 @@ func main() {
 	var cluster string //cluster name.
 	var ip string
 	var port int
+	var cmdEnable bool
+	var cmdAllow string
+	var wasmHttpAllow string
+	var logConfig logger.Config
+	var logMaxAge int
+	var otlpEndpoint string
+	var publicUrl string
+	var allowOrigins string
+	var trustProxies string
+	var authEnable bool
+	var adminToken string
+	var oidcIssuer string
+	var oidcAudience string
+	var oidcRoleClaim string
+	var oidcNsClaim string
+	var syncDir string
+	var syncInterval time.Duration
+	var syncPull bool
 	flag.StringVar(&mode, "m", "std", "[std] or [multi] running mode,default is std for standalone server.")
 	flag.StringVar(&redisUri, "r", "", "redis connection string.required for multi mode.")
 	flag.StringVar(&mongoStr, "mg", "", "mongodb uri.required for multi mode.")
 	flag.StringVar(&cluster, "c", "", "multi nodes cluster name.only effective for multi mode.")
 	flag.StringVar(&ip, "ip", "", "bind ip address.default is empty for all address.")
 	flag.IntVar(&port, "p", 8080, "bind port")
+	flag.BoolVar(&cmdEnable, "cmd", false, "enable COMMAND jobs which run executables on the host.")
+	flag.StringVar(&cmdAllow, "cmdAllow", "", "comma separated executables COMMAND jobs may run, * for any.")
+	flag.StringVar(&wasmHttpAllow, "wasmHttpAllow", "", "comma separated hosts the http_request of wasm plugins may reach, * for any.")
+	flag.StringVar(&logConfig.Level, "logLevel", "info", "minimum log level, debug/info/warn/error/fatal.")
+	flag.StringVar(&logConfig.Format, "logFormat", "text", "log format, text or json.")
+	flag.StringVar(&logConfig.Dir, "logDir", "", "log directory.default is ~/.traitor/log.")
+	flag.Int64Var(&logConfig.MaxSize, "logMaxSize", 100, "rotate the log file when it's larger than this many MB.")
+	flag.IntVar(&logMaxAge, "logMaxAge", 30, "days to keep the rotated log files.")
+	flag.IntVar(&logConfig.MaxBackups, "logMaxBackups", 10, "how many rotated log files are kept.")
+	flag.BoolVar(&logConfig.JobFiles, "logJobFiles", false, "also write the logs of each job into its own file.")
+	flag.StringVar(&otlpEndpoint, "otlp", "", "OTLP/HTTP endpoint the traces are exported to, e.g. http://localhost:4318.")
+	flag.StringVar(&publicUrl, "publicUrl", "", "the url the server is reached at, used in the links of alerts.")
+	flag.StringVar(&allowOrigins, "allowOrigins", "", "comma separated origins the websockets accept besides the same host, * for any.")
+	flag.StringVar(&trustProxies, "trustProxies", "", "comma separated ips or cidrs of the proxies whose X-Forwarded-For is trusted.")
+	flag.BoolVar(&authEnable, "auth", false, "require an API token or an OIDC bearer for the API.")
+	flag.StringVar(&adminToken, "adminToken", os.Getenv("TRAITOR_ADMIN_TOKEN"), "a static token with the admin role, default is $TRAITOR_ADMIN_TOKEN.")
+	flag.StringVar(&oidcIssuer, "oidcIssuer", "", "issuer of the OIDC bearer tokens, empty to accept API tokens only.")
+	flag.StringVar(&oidcAudience, "oidcAudience", "", "the audience the OIDC bearer tokens should have, required with -oidcIssuer.")
+	flag.StringVar(&oidcRoleClaim, "oidcRoleClaim", "roles", "the claim of the OIDC bearer tokens holding the role.")
+	flag.StringVar(&oidcNsClaim, "oidcNsClaim", "namespaces", "the claim of the OIDC bearer tokens holding the namespaces, * for all.")
+	flag.StringVar(&syncDir, "syncDir", "", "the directory of the job manifests to sync the jobs from, empty to not sync.")
+	flag.DurationVar(&syncInterval, "syncInterval", time.Minute, "how often the manifests are synced.")
+	flag.BoolVar(&syncPull, "syncPull", false, "git pull the checkout in syncDir before every sync.")
 	flag.Parse()
+	if oidcIssuer != "" && oidcAudience == "" {
+		panic("oidc audience is required with the oidc issuer.")
+	}
+	logConfig.MaxAge = time.Duration(logMaxAge) * time.Hour * 24
+	err := logger.Setup(logConfig)
+	if err != nil {
+		panic(err)
+	}
+	shutdownTracing, err := tracing.Setup(otlpEndpoint)
+	if err != nil {
+		panic(err)
+	}
+	defer shutdownTracing()
+	config.SetupConfig(config.CommandEnable, strconv.FormatBool(cmdEnable))
+	config.SetupConfig(config.CommandAllow, cmdAllow)
+	config.SetupConfig(config.WasmHttpAllow, wasmHttpAllow)
+	config.SetupConfig(config.PublicUrl, publicUrl)
+	config.SetupConfig(config.AllowOrigins, allowOrigins)
+	config.SetupConfig(config.TrustProxies, trustProxies)
+	config.SetupConfig(config.AuthEnable, strconv.FormatBool(authEnable))
+	config.SetupConfig(config.AdminToken, adminToken)
+	config.SetupConfig(config.OidcIssuer, oidcIssuer)
+	config.SetupConfig(config.OidcAudience, oidcAudience)
+	config.SetupConfig(config.OidcRoleClaim, oidcRoleClaim)
+	config.SetupConfig(config.OidcNsClaim, oidcNsClaim)
+	config.SetupConfig(config.SyncDir, syncDir)
+	config.SetupConfig(config.SyncInterval, syncInterval.String())
+	config.SetupConfig(config.SyncPull, strconv.FormatBool(syncPull))
 	if mode == "multi" {
 		if redisUri == "" {
 			panic("redis address is required.")
@@ -37,8 +119,18 @@ func main() {
 	r.NoRoute(func(ctx *gin.Context) { ctx.JSON(http.StatusNotFound, gin.H{}) })
 	defer server.Close()
 
-	err := r.Run(fmt.Sprintf("%s:%d", ip, port))
-	if err != nil {
+	// stop on SIGINT or SIGTERM, so the deferred calls like flushing the spans run.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := &http.Server{Addr: fmt.Sprintf("%s:%d", ip, port), Handler: r}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	err = srv.ListenAndServe()
+	if err != nil && errors.Is(err, http.ErrServerClosed) == false {
 		fmt.Println(err)
 		return
 	}
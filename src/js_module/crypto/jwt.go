@@ -0,0 +1,26 @@
+package crypto
+
+import (
+	"time"
+	"traitor/jwt"
+)
+
+// JwtSign signs the claims with a HMAC secret. alg defaults to HS256.
+func JwtSign(claims map[string]any, secret string, alg string) (string, error) {
+	return jwt.Sign(claims, []byte(secret), alg)
+}
+
+// JwtVerify checks the signature and the exp/nbf claims, and returns the claims.
+func JwtVerify(token string, secret string) (map[string]any, error) {
+	t, err := jwt.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if err = t.Verify([]byte(secret)); err != nil {
+		return nil, err
+	}
+	if err = t.CheckTime(time.Now(), 0); err != nil {
+		return nil, err
+	}
+	return t.Claims, nil
+}
@@ -0,0 +1,158 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	executor "github.com/KaniuBillows/traitor-plugin"
+	"github.com/dop251/goja"
+	"github.com/google/uuid"
+	"hash"
+	"strings"
+)
+
+const ModuleName = "crypto"
+
+type Module struct {
+}
+
+func GetModule() executor.Executable {
+	return &Module{}
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+func (m *Module) ModuleLoader(_ *goja.Runtime, module *goja.Object) {
+	obj := module.Get("exports").(*goja.Object)
+	obj.Set("md5", func(data string) string { return Sum("md5", data) })
+	obj.Set("sha1", func(data string) string { return Sum("sha1", data) })
+	obj.Set("sha256", func(data string) string { return Sum("sha256", data) })
+	obj.Set("sha512", func(data string) string { return Sum("sha512", data) })
+	obj.Set("hmac", Hmac)
+	obj.Set("aesEncrypt", AesEncrypt)
+	obj.Set("aesDecrypt", AesDecrypt)
+	obj.Set("randomBytes", RandomBytes)
+	obj.Set("uuid", uuid.NewString)
+	obj.Set("base64Encode", func(data string) string { return base64.StdEncoding.EncodeToString([]byte(data)) })
+	obj.Set("base64Decode", func(data string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(data)
+		return string(b), err
+	})
+	obj.Set("hexEncode", func(data string) string { return hex.EncodeToString([]byte(data)) })
+	obj.Set("hexDecode", func(data string) (string, error) {
+		b, err := hex.DecodeString(data)
+		return string(b), err
+	})
+	obj.Set("jwtSign", JwtSign)
+	obj.Set("jwtVerify", JwtVerify)
+}
+
+func newHash(alg string) (func() hash.Hash, error) {
+	switch strings.ToLower(alg) {
+	case "md5":
+		return md5.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm: %s", alg)
+	}
+}
+
+// Sum returns the hex digest of data.
+func Sum(alg string, data string) string {
+	h, err := newHash(alg)
+	if err != nil {
+		panic(err)
+	}
+	w := h()
+	w.Write([]byte(data))
+	return hex.EncodeToString(w.Sum(nil))
+}
+
+// Hmac returns the hex encoded hmac of data signed with key.
+func Hmac(alg string, key string, data string) (string, error) {
+	h, err := newHash(alg)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(h, []byte(key))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// RandomBytes returns n secure random bytes encoded as hex.
+func RandomBytes(n int) (string, error) {
+	if n <= 0 {
+		return "", errors.New("length must be positive")
+	}
+	buf := make([]byte, n)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func makeGcm(hexKey string) (cipher.AEAD, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, errors.New("key must be hex encoded")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// AesEncrypt encrypts plaintext with AES-GCM.
+// the key is hex encoded (16, 24 or 32 bytes), the result is base64(nonce|ciphertext).
+func AesEncrypt(hexKey string, plaintext string) (string, error) {
+	gcm, err := makeGcm(hexKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// AesDecrypt reverses AesEncrypt.
+func AesDecrypt(hexKey string, data string) (string, error) {
+	gcm, err := makeGcm(hexKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"github.com/dop251/goja"
+	"github.com/dop251/goja_nodejs/require"
+	"testing"
+)
+
+func runScript(t *testing.T, script string) goja.Value {
+	vm := goja.New()
+	registry := require.NewRegistry()
+	registry.RegisterNativeModule(ModuleName, GetModule().ModuleLoader)
+	registry.Enable(vm)
+	v, err := vm.RunString(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestHash(t *testing.T) {
+	v := runScript(t, `require('crypto').sha256('abc')`)
+	if v.String() != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("sha256 got %s", v.String())
+	}
+	v = runScript(t, `require('crypto').hmac('sha256', 'key', 'The quick brown fox jumps over the lazy dog')`)
+	if v.String() != "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8" {
+		t.Errorf("hmac got %s", v.String())
+	}
+}
+
+func TestAes(t *testing.T) {
+	v := runScript(t, `
+	var c = require('crypto')
+	var key = c.randomBytes(32)
+	c.aesDecrypt(key, c.aesEncrypt(key, 'hello traitor'))
+`)
+	if v.String() != "hello traitor" {
+		t.Errorf("aes round trip got %s", v.String())
+	}
+}
+
+func TestJwt(t *testing.T) {
+	v := runScript(t, `
+	var c = require('crypto')
+	var token = c.jwtSign({sub: 'job'}, 'secret', 'HS512')
+	c.jwtVerify(token, 'secret').sub
+`)
+	if v.String() != "job" {
+		t.Errorf("jwt claims got %s", v.String())
+	}
+	token, _ := JwtSign(map[string]any{"exp": 1}, "secret", "")
+	if _, err := JwtVerify(token, "secret"); err == nil {
+		t.Error("expired jwt should be rejected")
+	}
+	token, _ = JwtSign(map[string]any{}, "secret", "")
+	if _, err := JwtVerify(token, "other"); err == nil {
+		t.Error("jwt with wrong secret should be rejected")
+	}
+}
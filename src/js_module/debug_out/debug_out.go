@@ -36,16 +36,53 @@ func (c *Console) log(p func(string)) func(goja.FunctionCall) goja.Value {
 	}
 }
 
-func (c *Console) Log(s string) {
-	_, _ = c.writer.Write([]byte(s))
+// console levels, each is also the name of its console method.
+const (
+	LevelLog   = "log"
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
 
+var levels = []string{LevelLog, LevelDebug, LevelInfo, LevelWarn, LevelError}
+
+// LevelWriter a writer which also gets the level of the output.
+type LevelWriter interface {
+	WriteLevel(level string, p []byte) (int, error)
 }
-func (c *Console) Error(s string) {
+
+func (c *Console) write(level string, s string) {
+	if w, ok := c.writer.(LevelWriter); ok {
+		_, _ = w.WriteLevel(level, []byte(s))
+		return
+	}
 	_, _ = c.writer.Write([]byte(s))
+}
 
+func (c *Console) Log(s string) {
+	c.write(LevelLog, s)
+}
+func (c *Console) Error(s string) {
+	c.write(LevelError, s)
 }
 func (c *Console) Warn(s string) {
-	_, _ = c.writer.Write([]byte(s))
+	c.write(LevelWarn, s)
+}
+
+// set the method of every level on o, any other method of o writes at the log level.
+func (c *Console) set(o *goja.Object) {
+	for _, key := range o.Keys() {
+		if _, ok := goja.AssertFunction(o.Get(key)); ok {
+			o.Set(key, c.log(c.Log))
+		}
+	}
+	for _, level := range levels {
+		level := level
+		o.Set(level, c.log(func(s string) {
+			c.write(level, s)
+		}))
+	}
 }
 func Require(runtime *goja.Runtime, module *goja.Object) {
 	requireWithPrinter(defaultWriter)(runtime, module)
@@ -59,10 +96,7 @@ func requireWithPrinter(writer io.Writer) require.ModuleLoader {
 		}
 
 		c.util = require.Require(runtime, util.ModuleName).(*goja.Object)
-		o := module.Get("exports").(*goja.Object)
-		o.Set("log", c.log(c.Log))
-		o.Set("error", c.log(c.Error))
-		o.Set("warn", c.log(c.Warn))
+		c.set(module.Get("exports").(*goja.Object))
 	}
 }
 func Enable(runtime *goja.Runtime) {
@@ -75,7 +109,5 @@ func SetIoWriter(runtime *goja.Runtime, writer io.Writer) {
 		writer:  writer,
 	}
 	c.util = require.Require(runtime, util.ModuleName).(*goja.Object)
-	s.Set("log", c.log(c.Log))
-	s.Set("error", c.log(c.Error))
-	s.Set("warn", c.log(c.Warn))
+	c.set(s)
 }
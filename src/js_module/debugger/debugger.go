@@ -0,0 +1,248 @@
+package debugger
+
+import (
+	"encoding/json"
+	"github.com/dop251/goja"
+	"sync"
+)
+
+// commands sent by the client.
+const (
+	CmdStart          = "start"
+	CmdSetBreakpoints = "setBreakpoints"
+	CmdPause          = "pause"
+	CmdContinue       = "continue"
+	CmdStepOver       = "stepOver"
+	CmdStepInto       = "stepInto"
+	CmdStepOut        = "stepOut"
+	CmdEvaluate       = "evaluate"
+	CmdStop           = "stop"
+	// CmdPromote save the draft as the job's script, it's handled by the server.
+	CmdPromote = "promote"
+)
+
+// events sent to the client.
+const (
+	EventOutput    = "output"
+	EventPaused    = "paused"
+	EventResumed   = "resumed"
+	EventEvaluated = "evaluated"
+	EventError     = "error"
+	EventFinished  = "finished"
+	EventPromoted  = "promoted"
+)
+
+type Command struct {
+	Cmd        string `json:"cmd"`
+	Lines      []int  `json:"lines,omitempty"`
+	Expression string `json:"expression,omitempty"`
+	// Script the draft to run instead of the saved script, for start and promote.
+	Script string         `json:"script,omitempty"`
+	Params map[string]any `json:"params,omitempty"`
+}
+
+type Event struct {
+	Event    string            `json:"event"`
+	Text     string            `json:"text,omitempty"`
+	Line     int               `json:"line,omitempty"`
+	Reason   string            `json:"reason,omitempty"`
+	Locals   map[string]string `json:"locals,omitempty"`
+	Result   string            `json:"result,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Revision int64             `json:"revision,omitempty"`
+}
+
+type mode uint8
+
+const (
+	modeRun mode = iota
+	modePause
+	modeStepInto
+	modeStepOver
+	modeStepOut
+)
+
+// Debugger pause the instrumented script on breakpoints and steps.
+// the hook runs on the goroutine of the vm, it blocks there while paused.
+type Debugger struct {
+	send func(Event)
+
+	mu          sync.Mutex
+	vm          *goja.Runtime
+	breakpoints map[int]bool
+	mode        mode
+	depth       int
+	paused      bool
+	stopped     bool
+	commands    chan Command
+}
+
+func New(send func(Event)) *Debugger {
+	return &Debugger{
+		send:        send,
+		breakpoints: make(map[int]bool),
+		commands:    make(chan Command, 16),
+	}
+}
+
+// Attach install the hook into the vm.
+func (d *Debugger) Attach(vm *goja.Runtime) {
+	d.mu.Lock()
+	d.vm = vm
+	stopped := d.stopped
+	d.mu.Unlock()
+	_ = vm.Set(HookName, d.hook)
+	if stopped {
+		vm.Interrupt("debug stopped")
+	}
+}
+
+// SetBreakpoints replace the breakpoints by lines.
+func (d *Debugger) SetBreakpoints(lines []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = make(map[int]bool)
+	for _, l := range lines {
+		d.breakpoints[l] = true
+	}
+}
+
+// Handle a command from the client, commands other than setBreakpoints, pause and stop
+// only take effect while paused.
+func (d *Debugger) Handle(c Command) {
+	d.mu.Lock()
+	switch c.Cmd {
+	case CmdSetBreakpoints:
+		d.mu.Unlock()
+		d.SetBreakpoints(c.Lines)
+		return
+	case CmdPause:
+		d.mode = modePause
+		d.mu.Unlock()
+		return
+	case CmdStop:
+		d.stopped = true
+		if d.vm != nil {
+			d.vm.Interrupt("debug stopped")
+		}
+	}
+	paused := d.paused
+	d.mu.Unlock()
+	if paused {
+		select {
+		case d.commands <- c:
+		default: // too many pending commands.
+		}
+	}
+}
+
+// Close stop the script, e.g. after the client is gone.
+func (d *Debugger) Close() {
+	d.Handle(Command{Cmd: CmdStop})
+}
+
+func (d *Debugger) shouldPause(line int, depth int) (bool, string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case d.stopped:
+		return false, ""
+	case d.mode == modePause:
+		return true, "pause"
+	case d.mode == modeStepInto,
+		d.mode == modeStepOver && depth <= d.depth,
+		d.mode == modeStepOut && depth < d.depth:
+		return true, "step"
+	case d.breakpoints[line]:
+		return true, "breakpoint"
+	}
+	return false, ""
+}
+
+func (d *Debugger) hook(call goja.FunctionCall) goja.Value {
+	line := int(call.Argument(0).ToInteger())
+	eval, _ := goja.AssertFunction(call.Argument(1))
+	var names []string
+	_ = d.vm.ExportTo(call.Argument(2), &names)
+	depth := len(d.vm.CaptureCallStack(0, nil))
+
+	pause, reason := d.shouldPause(line, depth)
+	if pause == false {
+		return goja.Undefined()
+	}
+	// drop the commands left from the last pause.
+	for len(d.commands) > 0 {
+		<-d.commands
+	}
+	d.mu.Lock()
+	d.paused = true
+	d.mode = modeRun
+	d.mu.Unlock()
+	d.send(Event{Event: EventPaused, Line: line, Reason: reason, Locals: d.locals(eval, names)})
+
+	for c := range d.commands {
+		switch c.Cmd {
+		case CmdEvaluate:
+			res, err := d.evaluate(eval, c.Expression)
+			if err != nil {
+				d.send(Event{Event: EventEvaluated, Error: err.Error()})
+			} else {
+				d.send(Event{Event: EventEvaluated, Result: res})
+			}
+			continue
+		case CmdStepInto, CmdStepOver, CmdStepOut:
+			d.mu.Lock()
+			d.mode = map[string]mode{CmdStepInto: modeStepInto, CmdStepOver: modeStepOver, CmdStepOut: modeStepOut}[c.Cmd]
+			d.depth = depth
+			d.mu.Unlock()
+		case CmdContinue, CmdStop:
+		default:
+			continue
+		}
+		break
+	}
+	d.mu.Lock()
+	d.paused = false
+	d.mu.Unlock()
+	d.send(Event{Event: EventResumed})
+	return goja.Undefined()
+}
+
+func (d *Debugger) evaluate(eval goja.Callable, expression string) (res string, err error) {
+	if eval == nil {
+		return "", nil
+	}
+	v, err := eval(goja.Undefined(), d.vm.ToValue(expression))
+	if err != nil {
+		return "", err
+	}
+	return preview(v), nil
+}
+
+// locals evaluate the names declared in the scope, names not initialized yet are skipped.
+func (d *Debugger) locals(eval goja.Callable, names []string) map[string]string {
+	res := make(map[string]string)
+	for _, name := range names {
+		v, err := d.evaluate(eval, name)
+		if err == nil {
+			res[name] = v
+		}
+	}
+	return res
+}
+
+func preview(v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined"
+	}
+	if _, ok := goja.AssertFunction(v); ok {
+		return "function"
+	}
+	if _, ok := v.(*goja.Object); ok {
+		buffer, err := json.Marshal(v.Export())
+		if err == nil {
+			return string(buffer)
+		}
+	}
+	return v.String()
+}
@@ -0,0 +1,71 @@
+package debugger
+
+import (
+	"github.com/dop251/goja"
+	"strings"
+	"testing"
+	"time"
+)
+
+const script = `var total = 0
+function add(n) {
+	let doubled = n * 2
+	total += doubled
+	return total
+}
+add(1)
+add(2)
+total`
+
+func TestInstrument(t *testing.T) {
+	src, err := Instrument(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(src, "\n") != strings.Count(script, "\n") {
+		t.Error("instrument should keep the lines")
+	}
+	v, err := goja.New().RunString("function " + HookName + "() {}\n" + src)
+	if err != nil || v.ToInteger() != 6 {
+		t.Errorf("instrumented script got %v %v", v, err)
+	}
+}
+
+func TestDebugger(t *testing.T) {
+	src, _ := Instrument(script)
+	events := make(chan Event, 16)
+	d := New(func(e Event) { events <- e })
+	d.SetBreakpoints([]int{4})
+	vm := goja.New()
+	d.Attach(vm)
+	done := make(chan goja.Value)
+	go func() {
+		v, _ := vm.RunString(src)
+		done <- v
+	}()
+
+	e := <-events
+	if e.Event != EventPaused || e.Line != 4 || e.Locals["doubled"] != "2" || e.Locals["n"] != "1" {
+		t.Fatalf("expected pause on breakpoint, got %+v", e)
+	}
+	d.Handle(Command{Cmd: CmdEvaluate, Expression: "n + total"})
+	if e = <-events; e.Result != "1" {
+		t.Errorf("evaluate got %+v", e)
+	}
+	d.Handle(Command{Cmd: CmdStepOver})
+	<-events // resumed
+	if e = <-events; e.Event != EventPaused || e.Line != 5 || e.Reason != "step" {
+		t.Errorf("expected pause on next line, got %+v", e)
+	}
+	d.SetBreakpoints(nil)
+	d.Handle(Command{Cmd: CmdContinue})
+	<-events // resumed
+	select {
+	case v := <-done:
+		if v.ToInteger() != 6 {
+			t.Errorf("script result got %v", v)
+		}
+	case <-time.After(time.Second):
+		t.Error("script should finish after continue")
+	}
+}
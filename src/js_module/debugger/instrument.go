@@ -0,0 +1,160 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/file"
+	"github.com/dop251/goja/parser"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// HookName the global function called before every instrumented statement.
+const HookName = "__traitor_debug"
+
+type point struct {
+	offset int
+	line   int
+	names  []string
+}
+
+// Instrument insert a hook call before every statement of each statement list.
+// the hook gets the line, an eval closure of the statement's scope and the names declared in it.
+// lines are kept unchanged, so the breakpoints are the lines of the original script.
+func Instrument(src string) (string, error) {
+	prg, err := parser.ParseFile(nil, "", src, 0)
+	if err != nil {
+		return "", err
+	}
+	w := walker{file: prg.File}
+	w.list(prg.Body, nil)
+	sort.Slice(w.points, func(i, j int) bool {
+		return w.points[i].offset < w.points[j].offset
+	})
+
+	var b strings.Builder
+	last := 0
+	for _, p := range w.points {
+		if p.offset < last || p.offset > len(src) {
+			continue
+		}
+		names, _ := json.Marshal(p.names)
+		b.WriteString(src[last:p.offset])
+		b.WriteString(fmt.Sprintf("%s(%d,(__traitor_e)=>eval(__traitor_e),%s);", HookName, p.line, names))
+		last = p.offset
+	}
+	b.WriteString(src[last:])
+	return b.String(), nil
+}
+
+type walker struct {
+	file   *file.File
+	points []point
+}
+
+// list record the statements, their scope includes the names declared in the list.
+func (w *walker) list(stmts []ast.Statement, scope []string) {
+	names := append([]string{}, scope...)
+	for _, stmt := range stmts {
+		names = append(names, declaredNames(stmt)...)
+	}
+	for i, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *ast.FunctionDeclaration, *ast.ClassDeclaration, *ast.EmptyStatement:
+		case *ast.ExpressionStatement:
+			// keep the directive prologue, e.g. "use strict".
+			if _, ok := s.Expression.(*ast.StringLiteral); ok == false || i != 0 {
+				w.add(stmt, names)
+			}
+		default:
+			w.add(stmt, names)
+		}
+		w.walk(reflect.ValueOf(stmt), names)
+	}
+}
+
+func (w *walker) add(stmt ast.Statement, names []string) {
+	idx := stmt.Idx0()
+	w.points = append(w.points, point{
+		offset: int(idx) - w.file.Base(),
+		line:   w.file.Position(int(idx) - w.file.Base()).Line,
+		names:  names,
+	})
+}
+
+var astPkg = reflect.TypeOf(ast.Program{}).PkgPath()
+
+// walk find the nested statement lists and functions.
+func (w *walker) walk(v reflect.Value, scope []string) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() == false {
+			w.walk(v.Elem(), scope)
+		}
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct || v.Elem().Type().PkgPath() != astPkg {
+			return
+		}
+		switch n := v.Interface().(type) {
+		case *ast.BlockStatement:
+			w.list(n.List, scope)
+			return
+		case *ast.CaseStatement:
+			w.walk(reflect.ValueOf(n.Test), scope)
+			w.list(n.Consequent, scope)
+			return
+		case *ast.FunctionLiteral:
+			scope = append(append([]string{}, scope...), paramNames(n.ParameterList)...)
+		case *ast.ArrowFunctionLiteral:
+			scope = append(append([]string{}, scope...), paramNames(n.ParameterList)...)
+		}
+		w.walk(v.Elem(), scope)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				w.walk(v.Field(i), scope)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			w.walk(v.Index(i), scope)
+		}
+	}
+}
+
+func bindingNames(list []*ast.Binding) []string {
+	names := make([]string, 0)
+	for _, b := range list {
+		if id, ok := b.Target.(*ast.Identifier); ok {
+			names = append(names, id.Name.String())
+		}
+	}
+	return names
+}
+
+func paramNames(params *ast.ParameterList) []string {
+	if params == nil {
+		return nil
+	}
+	names := bindingNames(params.List)
+	if id, ok := params.Rest.(*ast.Identifier); ok {
+		names = append(names, id.Name.String())
+	}
+	return names
+}
+
+func declaredNames(stmt ast.Statement) []string {
+	switch s := stmt.(type) {
+	case *ast.VariableStatement:
+		return bindingNames(s.List)
+	case *ast.LexicalDeclaration:
+		return bindingNames(s.List)
+	case *ast.FunctionDeclaration:
+		if s.Function.Name != nil {
+			return []string{s.Function.Name.Name.String()}
+		}
+	}
+	return nil
+}
@@ -2,10 +2,15 @@ package http
 
 import (
 	"C"
+	"context"
 	executor "github.com/KaniuBillows/traitor-plugin"
 	"github.com/dop251/goja"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 	"io"
 	httpclient "net/http"
+	"traitor/js_module/runctx"
+	"traitor/tracing"
 )
 
 const ModuleName = "http"
@@ -39,8 +44,22 @@ func (m *Module) Require(e *executor.Executor) func(runtime *goja.Runtime, modul
 	}
 }
 
-func Get(url string, successRc chan *httpclient.Response, errRc chan *error) {
-	res, err := httpclient.Get(url)
+// Get the request is aborted when ctx is done, e.g. the run is cancelled.
+func Get(ctx context.Context, url string, successRc chan *httpclient.Response, errRc chan *error) {
+	ctx, span := tracing.Tracer().Start(ctx, "http GET", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPURLKey.String(url)))
+	req, err := httpclient.NewRequestWithContext(ctx, httpclient.MethodGet, url, nil)
+	if err != nil {
+		tracing.End(span, err)
+		errRc <- &err
+		return
+	}
+	tracing.Inject(ctx, req.Header)
+	res, err := httpclient.DefaultClient.Do(req)
+	if err == nil {
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(res.StatusCode))
+	}
+	tracing.End(span, err)
 	if err != nil {
 		errRc <- &err
 		return
@@ -65,7 +84,7 @@ func (u *Http) jsGet(call goja.FunctionCall) goja.Value {
 	}
 
 	u.e.Wait.Add(1)
-	go Get(url, rc, errRc)
+	go Get(runctx.Get(u.e.Vm), url, rc, errRc)
 
 	go u.callBack(callBack, errCallBack, rc, errRc)
 	return goja.Undefined()
@@ -87,11 +106,15 @@ func (u *Http) callBack(callback goja.Callable,
 				StatusCode:   res.StatusCode,
 				Status:       res.Status,
 			}
-			_, _ = callback(goja.Undefined(), u.e.Vm.ToValue(rsp))
+			if callback != nil {
+				_, _ = callback(goja.Undefined(), u.e.Vm.ToValue(rsp))
+			}
 		}
 	case err := <-errRc:
 		{
-			_, _ = errCallBack(goja.Undefined(), u.e.Vm.ToValue(err))
+			if errCallBack != nil {
+				_, _ = errCallBack(goja.Undefined(), u.e.Vm.ToValue(err))
+			}
 		}
 	}
 	u.e.Wait.Done()
@@ -3,73 +3,44 @@
 package js_module
 
 import (
-	"fmt"
+	"errors"
 	executor "github.com/KaniuBillows/traitor-plugin"
-	"github.com/mitchellh/go-homedir"
-	"os"
-	"path"
 	"plugin"
-	"traitor/logger"
 )
 
-func init() {
-	dirInit()
-	watchDir()
-}
-
-var dir string
-
-func dirInit() {
-	d, err := homedir.Dir()
+func loadSoFile(file string) (string, string, error) {
+	p, err := plugin.Open(file)
 	if err != nil {
-		panic(err)
-	}
-	d = fmt.Sprintf("%s/.traitor/plugin", d)
-	err = os.MkdirAll(d, 0744)
-	if err != nil {
-		panic(err)
-	}
-	dir = d
-}
-func watchDir() {
-	files, _ := os.ReadDir(dir)
-	for _, f := range files {
-		if f.IsDir() {
-			continue
-		}
-		name := f.Name()
-		if path.Ext(name) != ".so" {
-			continue
-		}
-
-		p, err := plugin.Open(dir + "/" + name)
-		if err != nil {
-			logger.Error(fmt.Sprintf("cannot load plugin file:%s  error:%s", name, err.Error()))
-			continue
-		}
-		loadPlugin(name, p)
+		return "", "", err
 	}
+	return loadPlugin(p)
 }
 
-func loadPlugin(moduleName string, p *plugin.Plugin) {
+func loadPlugin(p *plugin.Plugin) (string, string, error) {
 	moduleSymbol, err := p.Lookup("GetModule")
 	if err != nil {
-		logger.Error(err)
-		return
+		return "", "", err
 	}
 	switch moduleSymbol.(type) {
 	case func() executor.Executable:
 		{
-			module := moduleSymbol.(func() executor.Executable)
-			RegistryPlugin(module())
+			module := moduleSymbol.(func() executor.Executable)()
+			if err := checkName(module.GetName()); err != nil {
+				return "", "", err
+			}
+			RegistryPlugin(module)
+			return module.GetName(), ExecutableType, nil
 		}
 	case func() executor.AsyncExecutable:
 		{
-			module := moduleSymbol.(func() executor.AsyncExecutable)
-			RegistryAsyncPlugin(module())
+			module := moduleSymbol.(func() executor.AsyncExecutable)()
+			if err := checkName(module.GetName()); err != nil {
+				return "", "", err
+			}
+			RegistryAsyncPlugin(module)
+			return module.GetName(), AsyncExecutableType, nil
 		}
 	default:
-		logger.Error(fmt.Sprintf("load module file error: %s,Module not implemation AsyncExecutable or Executable", moduleName))
-		return
+		return "", "", errors.New("module not implementation AsyncExecutable or Executable")
 	}
 }
@@ -0,0 +1,122 @@
+package js_module
+
+import (
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/go-homedir"
+	"os"
+	"path"
+	"sync"
+	"time"
+	"traitor/logger"
+)
+
+func init() {
+	dirInit()
+	loadDir()
+	watchDir()
+}
+
+var dir string
+
+// wait for the file being written completely before loading it.
+const loadDelay = time.Millisecond * 500
+
+func dirInit() {
+	d, err := homedir.Dir()
+	if err != nil {
+		panic(err)
+	}
+	d = fmt.Sprintf("%s/.traitor/plugin", d)
+	err = os.MkdirAll(d, 0744)
+	if err != nil {
+		panic(err)
+	}
+	dir = d
+}
+
+// loadDir load all plugins in the directory at startup.
+func loadDir() {
+	files, _ := os.ReadDir(dir)
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		loadFile(f.Name())
+	}
+}
+
+// watchDir load the plugins which are added at runtime.
+func watchDir() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Error(fmt.Sprintf("cannot watch plugin dir:%s", err.Error()))
+		return
+	}
+	err = watcher.Add(dir)
+	if err != nil {
+		logger.Error(fmt.Sprintf("cannot watch plugin dir:%s", err.Error()))
+		_ = watcher.Close()
+		return
+	}
+	go func() {
+		var timerMu sync.Mutex
+		timers := make(map[string]*time.Timer)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if ok == false {
+					return
+				}
+				if event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+					continue
+				}
+				name := path.Base(event.Name)
+				timerMu.Lock()
+				if t, ok := timers[name]; ok {
+					t.Reset(loadDelay)
+				} else {
+					timers[name] = time.AfterFunc(loadDelay, func() {
+						timerMu.Lock()
+						delete(timers, name)
+						timerMu.Unlock()
+						loadFile(name)
+					})
+				}
+				timerMu.Unlock()
+			case err, ok := <-watcher.Errors:
+				if ok == false {
+					return
+				}
+				logger.Error(fmt.Sprintf("watch plugin dir error:%s", err.Error()))
+			}
+		}
+	}()
+}
+
+// loadFile load the plugin by its extension.
+func loadFile(name string) {
+	var load func(file string) (string, string, error)
+	switch path.Ext(name) {
+	case ".so":
+		// go plugins cannot be unloaded or opened twice.
+		if info, ok := getPluginInfo(name); ok && info.Error == "" {
+			return
+		}
+		load = loadSoFile
+	case rpcPluginExt:
+		load = loadRpcFile
+	case wasmPluginExt:
+		load = loadWasmFile
+	default:
+		return
+	}
+	info := PluginInfo{File: name, LoadTime: time.Now()}
+	var err error
+	info.Name, info.Type, err = load(dir + "/" + name)
+	if err != nil {
+		info.Error = err.Error()
+		logger.Error(fmt.Sprintf("cannot load plugin file:%s  error:%s", name, err.Error()))
+	}
+	setPluginInfo(info)
+}
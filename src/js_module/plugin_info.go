@@ -0,0 +1,53 @@
+package js_module
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ExecutableType      = "Executable"
+	AsyncExecutableType = "AsyncExecutable"
+)
+
+// PluginInfo the load state of a plugin file.
+type PluginInfo struct {
+	Name     string    `json:"name,omitempty"`
+	File     string    `json:"file"`
+	Type     string    `json:"type,omitempty"`
+	LoadTime time.Time `json:"loadTime"`
+	Error    string    `json:"error,omitempty"`
+}
+
+var (
+	infoMu      sync.RWMutex
+	pluginInfos = make(map[string]PluginInfo) // key is the file name.
+)
+
+func setPluginInfo(info PluginInfo) {
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	pluginInfos[info.File] = info
+}
+
+func getPluginInfo(file string) (PluginInfo, bool) {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	info, ok := pluginInfos[file]
+	return info, ok
+}
+
+// GetPlugins list the plugin files found in the plugin directory, sorted by file name.
+func GetPlugins() []PluginInfo {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	res := make([]PluginInfo, 0, len(pluginInfos))
+	for _, info := range pluginInfos {
+		res = append(res, info)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].File < res[j].File
+	})
+	return res
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package js_module
+
+import "errors"
+
+func loadSoFile(_ string) (string, string, error) {
+	return "", "", errors.New("go plugins are only supported on linux")
+}
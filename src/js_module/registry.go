@@ -1,10 +1,14 @@
 package js_module
 
 import (
+	"errors"
+	"fmt"
 	executor "github.com/KaniuBillows/traitor-plugin"
 	"github.com/dop251/goja_nodejs/console"
 	"github.com/dop251/goja_nodejs/require"
 	"github.com/dop251/goja_nodejs/util"
+	"sync"
+	"traitor/js_module/crypto"
 	"traitor/js_module/debug_out"
 	"traitor/js_module/http"
 )
@@ -12,42 +16,97 @@ import (
 // register inside modules.
 func init() {
 	RegistryAsyncPlugin(http.GetModule())
+	RegistryPlugin(crypto.GetModule())
 }
 
+var (
+	mu          sync.RWMutex
+	plugins     = make([]executor.AsyncExecutable, 0)
+	syncPlugins = make([]executor.Executable, 0)
+	generation  uint64 // bumped whenever a module is registered or replaced.
+)
+
 func RegistryPlugin(p executor.Executable) {
-	// add into global registry
-	require.RegisterNativeModule(p.GetName(), p.ModuleLoader)
+	mu.Lock()
+	syncPlugins = append(syncPlugins, p)
+	mu.Unlock()
+	modulesChanged()
 }
 
 func RegistryAsyncPlugin(p executor.AsyncExecutable) {
+	mu.Lock()
 	plugins = append(plugins, p)
+	mu.Unlock()
+	modulesChanged()
 }
 
-var plugins = make([]executor.AsyncExecutable, 0)
+// reserved the modules enabled besides the registered ones.
+var reserved = map[string]bool{"console": true, util.ModuleName: true, debug_out.ModuleName: true}
 
-func LoadModules(exec *executor.Executor) {
-	if len(plugins) > 0 {
-		var registry = require.NewRegistry()
-		for _, plugin := range plugins {
-			loader := plugin.Require(exec)
-			name := plugin.GetName()
-			registry.RegisterNativeModule(name, loader)
+// checkName a plugin could not shadow a built-in module or another plugin.
+func checkName(name string) error {
+	if name == "" {
+		return errors.New("plugin name cannot be empty")
+	}
+	if reserved[name] {
+		return fmt.Errorf("plugin name %s is reserved", name)
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, p := range syncPlugins {
+		if p.GetName() == name {
+			return fmt.Errorf("module %s exists already", name)
+		}
+	}
+	for _, p := range plugins {
+		if p.GetName() == name {
+			return fmt.Errorf("module %s exists already", name)
 		}
-		registry.Enable(exec.Vm)
 	}
+	return nil
+}
+
+// ModulesGeneration the generation of the modules, a runtime loaded with an older one misses a module
+// registered or replaced at runtime since.
+func ModulesGeneration() uint64 {
+	mu.RLock()
+	defer mu.RUnlock()
+	return generation
+}
+
+func modulesChanged() {
+	mu.Lock()
+	generation++
+	mu.Unlock()
+}
+
+// registerModules the generation of the registered modules is returned.
+func registerModules(exec *executor.Executor, registry *require.Registry) uint64 {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, plugin := range syncPlugins {
+		registry.RegisterNativeModule(plugin.GetName(), plugin.ModuleLoader)
+	}
+	for _, plugin := range plugins {
+		loader := plugin.Require(exec)
+		name := plugin.GetName()
+		registry.RegisterNativeModule(name, loader)
+	}
+	return generation
+}
+
+// LoadModules the generation of the loaded modules is returned, see ModulesGeneration.
+func LoadModules(exec *executor.Executor) uint64 {
+	var registry = require.NewRegistry()
+	gen := registerModules(exec, registry)
+	registry.Enable(exec.Vm)
 	console.Enable(exec.Vm)
+	return gen
 }
 
 func LoadModulesForDebugMode(exec *executor.Executor) {
 	var registry = require.NewRegistry()
-	if len(plugins) > 0 {
-		for _, plugin := range plugins {
-			loader := plugin.Require(exec)
-			name := plugin.GetName()
-			registry.RegisterNativeModule(name, loader)
-		}
-		registry.Enable(exec.Vm)
-	}
+	registerModules(exec, registry)
 	registry.RegisterNativeModule(debug_out.ModuleName, debug_out.Require)
 	registry.RegisterNativeModule(util.ModuleName, util.Require)
 	registry.Enable(exec.Vm)
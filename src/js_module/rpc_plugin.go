@@ -0,0 +1,255 @@
+package js_module
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	executor "github.com/KaniuBillows/traitor-plugin"
+	"github.com/dop251/goja"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os/exec"
+	"sync"
+	"time"
+	"traitor/js_module/runctx"
+	"traitor/logger"
+	"traitor/rpcplugin"
+)
+
+const (
+	rpcPluginExt   = ".plugin"
+	RpcPluginType  = "RpcPlugin"
+	rpcCallTimeout = time.Second * 30
+)
+
+var (
+	rpcMu      sync.Mutex
+	rpcPlugins = make(map[string]*rpcPlugin) // key is the file path.
+)
+
+// rpcPlugin a plugin running in its own process.
+// the process is restarted on the next call after it crashed.
+type rpcPlugin struct {
+	file      string
+	mu        sync.Mutex
+	name      string
+	functions []string
+	cmd       *exec.Cmd
+	client    *rpc.Client
+	exited    chan struct{}
+}
+
+func loadRpcFile(file string) (string, string, error) {
+	rpcMu.Lock()
+	p, ok := rpcPlugins[file]
+	rpcMu.Unlock()
+	if ok {
+		// the executable was replaced, restart it.
+		p.mu.Lock()
+		p.stop()
+		err := p.start()
+		p.mu.Unlock()
+		modulesChanged()
+		return p.name, RpcPluginType, err
+	}
+	p = &rpcPlugin{file: file}
+	p.mu.Lock()
+	err := p.start()
+	p.mu.Unlock()
+	if err == nil {
+		err = checkName(p.name)
+	}
+	if err != nil {
+		p.stop()
+		return "", RpcPluginType, err
+	}
+	rpcMu.Lock()
+	rpcPlugins[file] = p
+	rpcMu.Unlock()
+	RegistryAsyncPlugin(p)
+	return p.name, RpcPluginType, nil
+}
+
+// start launch the process and describe the module. caller must hold mu.
+func (p *rpcPlugin) start() error {
+	cmd := exec.Command(p.file)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	go p.forwardLog(stderr)
+	client := jsonrpc.NewClient(&stdioConn{Reader: stdout, WriteCloser: stdin})
+	exited := make(chan struct{})
+	go func() {
+		err := cmd.Wait()
+		logger.Error(fmt.Sprintf("rpc plugin exited:%s %v", p.file, err))
+		_ = client.Close()
+		close(exited)
+	}()
+	p.cmd = cmd
+	p.client = client
+	p.exited = exited
+
+	var reply rpcplugin.DescribeReply
+	err = call(context.Background(), client, rpcplugin.DescribeMethod, rpcplugin.DescribeArgs{}, &reply)
+	if err != nil {
+		p.stop()
+		return fmt.Errorf("describe plugin error:%s", err.Error())
+	}
+	if p.name == "" {
+		p.name = reply.Name // set once before the plugin is registered, so it's read without mu.
+	} else if p.name != reply.Name {
+		return fmt.Errorf("plugin name changed from %s to %s", p.name, reply.Name)
+	}
+	p.functions = reply.Functions
+	return nil
+}
+
+// stop kill the process. caller must hold mu.
+func (p *rpcPlugin) stop() {
+	if p.client != nil {
+		_ = p.client.Close()
+		p.client = nil
+	}
+	if p.cmd != nil && p.cmd.Process != nil {
+		_ = p.cmd.Process.Kill()
+		p.cmd = nil
+	}
+}
+
+func (p *rpcPlugin) isExited() bool {
+	select {
+	case <-p.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *rpcPlugin) forwardLog(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		logger.Info(fmt.Sprintf("[plugin %s] %s", p.name, scanner.Text()))
+	}
+}
+
+// call with timeout, or until ctx is done. the call is not serialized with the others.
+func call(ctx context.Context, client *rpc.Client, method string, args any, reply any) error {
+	c := client.Go(method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-c.Done:
+		return c.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(rpcCallTimeout):
+		return errors.New("plugin call timeout")
+	}
+}
+
+// connect the client of the process, restart the process if it has crashed.
+func (p *rpcPlugin) connect() (*rpc.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client == nil || p.isExited() {
+		p.stop()
+		err := p.start()
+		if err != nil {
+			p.stop()
+			return nil, fmt.Errorf("plugin %s is not available:%s", p.name, err.Error())
+		}
+	}
+	return p.client, nil
+}
+
+// drop kill the process of the client, unless it was restarted already.
+func (p *rpcPlugin) drop(client *rpc.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client == client {
+		p.stop()
+	}
+}
+
+// Invoke call the function, the call is abandoned when ctx is done.
+func (p *rpcPlugin) Invoke(ctx context.Context, function string, args []json.RawMessage) (json.RawMessage, error) {
+	client, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	var reply rpcplugin.CallReply
+	err = call(ctx, client, rpcplugin.CallMethod, rpcplugin.CallArgs{Function: function, Args: args}, &reply)
+	if _, ok := err.(rpc.ServerError); ok {
+		return nil, err
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, err // the process is fine, the run is not waiting for it anymore.
+	}
+	if err != nil {
+		// the connection is broken, or the process doesn't answer.
+		p.drop(client)
+		return nil, fmt.Errorf("plugin %s crashed:%s", p.name, err.Error())
+	}
+	return reply.Result, nil
+}
+
+func (p *rpcPlugin) GetName() string {
+	return p.name
+}
+
+func (p *rpcPlugin) Require(exec *executor.Executor) func(runtime *goja.Runtime, module *goja.Object) {
+	return func(runtime *goja.Runtime, module *goja.Object) {
+		obj := module.Get("exports").(*goja.Object)
+		p.mu.Lock()
+		functions := p.functions
+		p.mu.Unlock()
+		for _, name := range functions {
+			obj.Set(name, p.jsFunc(exec.Vm, name))
+		}
+	}
+}
+
+func (p *rpcPlugin) jsFunc(vm *goja.Runtime, function string) func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		args := make([]json.RawMessage, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			buffer, err := json.Marshal(arg.Export())
+			if err != nil {
+				panic(vm.NewGoError(err))
+			}
+			args[i] = buffer
+		}
+		res, err := p.Invoke(runctx.Get(vm), function, args)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		var value any
+		if len(res) > 0 {
+			err = json.Unmarshal(res, &value)
+			if err != nil {
+				panic(vm.NewGoError(err))
+			}
+		}
+		return vm.ToValue(value)
+	}
+}
+
+// stdioConn join the pipes of the process.
+type stdioConn struct {
+	io.Reader
+	io.WriteCloser
+}
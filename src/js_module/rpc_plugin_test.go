@@ -0,0 +1,94 @@
+package js_module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	executor "github.com/KaniuBillows/traitor-plugin"
+	"os"
+	"testing"
+	"time"
+	"traitor/js_module/runctx"
+	"traitor/rpcplugin"
+)
+
+const rpcPluginEnv = "TRAITOR_RPC_PLUGIN_TEST"
+
+// TestMain the test binary serves as the plugin when it's launched by the test.
+func TestMain(m *testing.M) {
+	if os.Getenv(rpcPluginEnv) == "1" {
+		_ = rpcplugin.Serve("echo", map[string]rpcplugin.Func{
+			"echo": func(args []json.RawMessage) (any, error) {
+				var v any
+				_ = json.Unmarshal(args[0], &v)
+				return v, nil
+			},
+			"fail": func(args []json.RawMessage) (any, error) {
+				return nil, errors.New("failed")
+			},
+			"sleep": func(args []json.RawMessage) (any, error) {
+				time.Sleep(5 * time.Second)
+				return nil, nil
+			},
+			"crash": func(args []json.RawMessage) (any, error) {
+				os.Exit(1)
+				return nil, nil
+			},
+		})
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestRpcPlugin(t *testing.T) {
+	t.Setenv(rpcPluginEnv, "1")
+	name, typ, err := loadRpcFile(os.Args[0])
+	if err != nil || name != "echo" || typ != RpcPluginType {
+		t.Fatalf("load rpc plugin: %s %s %v", name, typ, err)
+	}
+	exec := executor.MakeExecutor()
+	LoadModules(exec)
+	v, err := exec.Vm.RunString(`require('echo').echo({a: [1, 2]}).a[1]`)
+	if err != nil || v.ToInteger() != 2 {
+		t.Errorf("echo got %v %v", v, err)
+	}
+	_, err = exec.Vm.RunString(`require('echo').fail()`)
+	if err == nil {
+		t.Error("error of the plugin should be thrown")
+	}
+	_, err = exec.Vm.RunString(`require('echo').crash()`)
+	if err == nil {
+		t.Error("crash of the plugin should be thrown")
+	}
+	// restarted after crash.
+	v, err = exec.Vm.RunString(`require('echo').echo('again')`)
+	if err != nil || v.String() != "again" {
+		t.Errorf("echo after crash got %v %v", v, err)
+	}
+
+	// a pending call neither blocks the others nor outlives its run.
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	slow := executor.MakeExecutor()
+	LoadModules(slow)
+	runctx.Set(slow.Vm, ctx)
+	defer runctx.Remove(slow.Vm)
+	done := make(chan error, 1)
+	go func() {
+		_, err := slow.Vm.RunString(`require('echo').sleep()`)
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	v, err = exec.Vm.RunString(`require('echo').echo('meanwhile')`)
+	if err != nil || v.String() != "meanwhile" {
+		t.Errorf("echo during a pending call got %v %v", v, err)
+	}
+	select {
+	case err = <-done:
+		if err == nil {
+			t.Error("the cancelled call should be thrown")
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("the call was not cancelled with its run")
+	}
+}
@@ -0,0 +1,26 @@
+package runctx
+
+import (
+	"context"
+	"github.com/dop251/goja"
+	"sync"
+)
+
+// contexts the context of the run each vm executes, modules use it to abort their pending calls.
+var contexts sync.Map
+
+func Set(vm *goja.Runtime, ctx context.Context) {
+	contexts.Store(vm, ctx)
+}
+
+// Get the context of the vm, context.Background if the vm isn't running a job, e.g. debug.
+func Get(vm *goja.Runtime) context.Context {
+	if ctx, ok := contexts.Load(vm); ok {
+		return ctx.(context.Context)
+	}
+	return context.Background()
+}
+
+func Remove(vm *goja.Runtime) {
+	contexts.Delete(vm)
+}
@@ -0,0 +1,381 @@
+package js_module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	executor "github.com/KaniuBillows/traitor-plugin"
+	"github.com/dop251/goja"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"traitor/config"
+	"traitor/js_module/runctx"
+	"traitor/logger"
+)
+
+// WebAssembly plugin ABI.
+//
+// A ".wasm" file in the plugin directory is loaded as the module named after the file,
+// e.g. "greet.wasm" is require('greet'). The module must export:
+//
+//	memory                                the linear memory.
+//	alloc(size i32) -> i32                allocate size bytes for the host to write into.
+//	<name>(ptr i32, len i32) -> i64       every other function of this signature is exported to scripts.
+//
+// The arguments of a call are written as a JSON array into memory returned by alloc.
+// The result is the JSON at (ptr << 32 | len) of the returned i64, len 0 for undefined.
+// Each call runs on a fresh instance, so no state is kept between calls.
+//
+// The module may import these host functions from "traitor":
+//
+//	log(ptr i32, len i32)                 write the string into the server's log.
+//	error(ptr i32, len i32)               the call fails with this message after it returns.
+//	http_request(ptr i32, len i32) -> i64 perform {"method","url","headers","body"},
+//	                                      returns {"status","body","error"} like the result above.
+//
+// http_request only reaches the hosts of -wasmHttpAllow, it fails for any host if none is given.
+// A response body larger than wasmHttpMaxBody fails the request.
+const (
+	wasmPluginExt   = ".wasm"
+	WasmPluginType  = "WasmPlugin"
+	wasmHostModule  = "traitor"
+	wasmMemoryPages = 256 // 16MB.
+	wasmCallTimeout = time.Second * 30
+	wasmHttpMaxBody = 1 << 20 // 1MB.
+)
+
+var (
+	wasmOnce     sync.Once
+	wasmRuntime  wazero.Runtime
+	wasmMu       sync.Mutex
+	wasmPlugins  = make(map[string]*wasmPlugin) // key is the file path.
+	wasmInstance uint64
+	// wasmHttpClient the redirects are checked against the allowed hosts as well.
+	wasmHttpClient = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return checkWasmHost(req.URL)
+		},
+	}
+)
+
+type wasmCallKey struct{}
+
+// wasmCall the state of one call, shared with the host functions.
+type wasmCall struct {
+	err string
+}
+
+type wasmPlugin struct {
+	name      string
+	mu        sync.RWMutex
+	compiled  wazero.CompiledModule
+	functions []string
+}
+
+func getWasmRuntime() wazero.Runtime {
+	wasmOnce.Do(func() {
+		ctx := context.Background()
+		config := wazero.NewRuntimeConfig().
+			WithMemoryLimitPages(wasmMemoryPages).
+			WithCloseOnContextDone(true)
+		wasmRuntime = wazero.NewRuntimeWithConfig(ctx, config)
+		_, err := wasmRuntime.NewHostModuleBuilder(wasmHostModule).
+			NewFunctionBuilder().WithFunc(wasmLog).Export("log").
+			NewFunctionBuilder().WithFunc(wasmError).Export("error").
+			NewFunctionBuilder().WithFunc(wasmHttpRequest).Export("http_request").
+			Instantiate(ctx)
+		if err != nil {
+			panic(err)
+		}
+	})
+	return wasmRuntime
+}
+
+func loadWasmFile(file string) (string, string, error) {
+	buffer, err := os.ReadFile(file)
+	if err != nil {
+		return "", WasmPluginType, err
+	}
+	ctx := context.Background()
+	compiled, err := getWasmRuntime().CompileModule(ctx, buffer)
+	if err != nil {
+		return "", WasmPluginType, err
+	}
+	functions, err := wasmExports(compiled)
+	if err != nil {
+		_ = compiled.Close(ctx)
+		return "", WasmPluginType, err
+	}
+	name := strings.TrimSuffix(path.Base(file), wasmPluginExt)
+
+	wasmMu.Lock()
+	p, ok := wasmPlugins[file]
+	if ok == false {
+		if err = checkName(name); err != nil {
+			wasmMu.Unlock()
+			_ = compiled.Close(ctx)
+			return "", WasmPluginType, err
+		}
+		p = &wasmPlugin{name: name}
+		wasmPlugins[file] = p
+	}
+	wasmMu.Unlock()
+
+	p.mu.Lock()
+	old := p.compiled
+	p.compiled = compiled
+	p.functions = functions
+	p.mu.Unlock()
+	if old != nil {
+		// the file was replaced.
+		_ = old.Close(ctx)
+		modulesChanged()
+	} else {
+		RegistryAsyncPlugin(p)
+	}
+	return name, WasmPluginType, nil
+}
+
+// wasmExports check the ABI and find the functions exported to scripts.
+func wasmExports(compiled wazero.CompiledModule) ([]string, error) {
+	if _, ok := compiled.ExportedMemories()["memory"]; ok == false {
+		return nil, errors.New("wasm module must export memory")
+	}
+	alloc, ok := compiled.ExportedFunctions()["alloc"]
+	if ok == false || matchTypes(alloc.ParamTypes(), api.ValueTypeI32) == false ||
+		matchTypes(alloc.ResultTypes(), api.ValueTypeI32) == false {
+		return nil, errors.New("wasm module must export alloc(i32) i32")
+	}
+	functions := make([]string, 0)
+	for name, def := range compiled.ExportedFunctions() {
+		if name == "alloc" {
+			continue
+		}
+		if matchTypes(def.ParamTypes(), api.ValueTypeI32, api.ValueTypeI32) &&
+			matchTypes(def.ResultTypes(), api.ValueTypeI64) {
+			functions = append(functions, name)
+		}
+	}
+	return functions, nil
+}
+
+func matchTypes(types []api.ValueType, expected ...api.ValueType) bool {
+	if len(types) != len(expected) {
+		return false
+	}
+	for i := range types {
+		if types[i] != expected[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Invoke run the function on a new instance, input is the json of the arguments.
+// the call is aborted when parent is done.
+func (p *wasmPlugin) Invoke(parent context.Context, function string, input []byte) ([]byte, error) {
+	p.mu.RLock()
+	compiled := p.compiled
+	p.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(parent, wasmCallTimeout)
+	defer cancel()
+	call := &wasmCall{}
+	ctx = context.WithValue(ctx, wasmCallKey{}, call)
+	instance := fmt.Sprintf("%s.%d", p.name, atomic.AddUint64(&wasmInstance, 1))
+	mod, err := getWasmRuntime().InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(instance))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = mod.Close(context.Background())
+	}()
+	ptr, err := wasmWrite(ctx, mod, input)
+	if err != nil {
+		return nil, err
+	}
+	res, err := mod.ExportedFunction(function).Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return nil, err
+	}
+	if call.err != "" {
+		return nil, errors.New(call.err)
+	}
+	return wasmRead(mod, res[0])
+}
+
+// wasmWrite copy data into the guest memory allocated by alloc.
+func wasmWrite(ctx context.Context, mod api.Module, data []byte) (uint32, error) {
+	res, err := mod.ExportedFunction("alloc").Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, err
+	}
+	ptr := uint32(res[0])
+	if mod.Memory().Write(ptr, data) == false {
+		return 0, errors.New("wasm alloc returned invalid memory")
+	}
+	return ptr, nil
+}
+
+// wasmRead copy the data of a packed (ptr << 32 | len) out of the guest memory.
+func wasmRead(mod api.Module, packed uint64) ([]byte, error) {
+	ptr, size := uint32(packed>>32), uint32(packed)
+	if size == 0 {
+		return nil, nil
+	}
+	buffer, ok := mod.Memory().Read(ptr, size)
+	if ok == false {
+		return nil, errors.New("wasm returned invalid memory")
+	}
+	res := make([]byte, size)
+	copy(res, buffer)
+	return res, nil
+}
+
+func wasmString(mod api.Module, ptr uint32, size uint32) string {
+	buffer, ok := mod.Memory().Read(ptr, size)
+	if ok == false {
+		return ""
+	}
+	return string(buffer)
+}
+
+func wasmLog(_ context.Context, mod api.Module, ptr uint32, size uint32) {
+	logger.Info(fmt.Sprintf("[wasm %s] %s", mod.Name(), wasmString(mod, ptr, size)))
+}
+
+func wasmError(ctx context.Context, mod api.Module, ptr uint32, size uint32) {
+	if call, ok := ctx.Value(wasmCallKey{}).(*wasmCall); ok {
+		call.err = wasmString(mod, ptr, size)
+	}
+}
+
+type wasmHttpReq struct {
+	Method  string            `json:"method"`
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+type wasmHttpRes struct {
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+	Error  string `json:"error,omitempty"`
+}
+
+func wasmHttpRequest(ctx context.Context, mod api.Module, ptr uint32, size uint32) uint64 {
+	var req wasmHttpReq
+	var res wasmHttpRes
+	err := json.Unmarshal([]byte(wasmString(mod, ptr, size)), &req)
+	if err == nil {
+		res, err = doWasmHttp(ctx, req)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	buffer, _ := json.Marshal(res)
+	p, err := wasmWrite(ctx, mod, buffer)
+	if err != nil {
+		return 0
+	}
+	return uint64(p)<<32 | uint64(len(buffer))
+}
+
+// checkWasmHost whether http_request could reach the host of the url.
+func checkWasmHost(u *url.URL) error {
+	for _, allowed := range config.GetConfigList(config.WasmHttpAllow) {
+		if allowed == "*" || strings.EqualFold(allowed, u.Hostname()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host is not allowed: %s", u.Hostname())
+}
+
+func doWasmHttp(ctx context.Context, r wasmHttpReq) (wasmHttpRes, error) {
+	var res wasmHttpRes
+	if r.Method == "" {
+		r.Method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.Url, strings.NewReader(r.Body))
+	if err != nil {
+		return res, err
+	}
+	if err = checkWasmHost(req.URL); err != nil {
+		return res, err
+	}
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+	rsp, err := wasmHttpClient.Do(req)
+	if err != nil {
+		return res, err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(rsp.Body)
+	res.Status = rsp.StatusCode
+	body, err := io.ReadAll(io.LimitReader(rsp.Body, wasmHttpMaxBody+1))
+	if err != nil {
+		return res, err
+	}
+	if len(body) > wasmHttpMaxBody {
+		return res, fmt.Errorf("response body is larger than %d bytes", wasmHttpMaxBody)
+	}
+	res.Body = string(body)
+	return res, nil
+}
+
+func (p *wasmPlugin) GetName() string {
+	return p.name
+}
+
+func (p *wasmPlugin) Require(exec *executor.Executor) func(runtime *goja.Runtime, module *goja.Object) {
+	return func(runtime *goja.Runtime, module *goja.Object) {
+		obj := module.Get("exports").(*goja.Object)
+		p.mu.RLock()
+		functions := p.functions
+		p.mu.RUnlock()
+		for _, name := range functions {
+			obj.Set(name, p.jsFunc(exec.Vm, name))
+		}
+	}
+}
+
+func (p *wasmPlugin) jsFunc(vm *goja.Runtime, function string) func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		args := make([]any, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			args[i] = arg.Export()
+		}
+		input, err := json.Marshal(args)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		res, err := p.Invoke(runctx.Get(vm), function, input)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		if len(res) == 0 {
+			return goja.Undefined()
+		}
+		var value any
+		err = json.Unmarshal(res, &value)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		return vm.ToValue(value)
+	}
+}
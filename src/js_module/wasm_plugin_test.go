@@ -0,0 +1,101 @@
+package js_module
+
+import (
+	"context"
+	executor "github.com/KaniuBillows/traitor-plugin"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"traitor/config"
+)
+
+// echoWasm a hand assembled module:
+//
+//	(module
+//	  (import "traitor" "log" (func (param i32 i32)))
+//	  (memory (export "memory") 1)
+//	  (func (export "alloc") (param i32) (result i32) i32.const 1024)
+//	  (func (export "echo") (param i32 i32) (result i64)
+//	    local.get 0 i64.extend_i32_u i64.const 32 i64.shl
+//	    local.get 1 i64.extend_i32_u i64.or))
+var echoWasm = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	// types.
+	0x01, 0x11, 0x03,
+	0x60, 0x02, 0x7f, 0x7f, 0x00,
+	0x60, 0x01, 0x7f, 0x01, 0x7f,
+	0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e,
+	// imports.
+	0x02, 0x0f, 0x01,
+	0x07, 't', 'r', 'a', 'i', 't', 'o', 'r', 0x03, 'l', 'o', 'g', 0x00, 0x00,
+	// functions.
+	0x03, 0x03, 0x02, 0x01, 0x02,
+	// memory.
+	0x05, 0x03, 0x01, 0x00, 0x01,
+	// exports.
+	0x07, 0x19, 0x03,
+	0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+	0x05, 'a', 'l', 'l', 'o', 'c', 0x00, 0x01,
+	0x04, 'e', 'c', 'h', 'o', 0x00, 0x02,
+	// code.
+	0x0a, 0x14, 0x02,
+	0x05, 0x00, 0x41, 0x80, 0x08, 0x0b,
+	0x0c, 0x00, 0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b,
+}
+
+func TestWasmPlugin(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wasm_echo.wasm")
+	err := os.WriteFile(file, echoWasm, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, typ, err := loadWasmFile(file)
+	if err != nil || name != "wasm_echo" || typ != WasmPluginType {
+		t.Fatalf("load wasm plugin: %s %s %v", name, typ, err)
+	}
+	exec := executor.MakeExecutor()
+	LoadModules(exec)
+	// the arguments array is echoed back.
+	v, err := exec.Vm.RunString(`require('wasm_echo').echo('hello', {n: 1})[1].n`)
+	if err != nil || v.ToInteger() != 1 {
+		t.Errorf("echo got %v %v", v, err)
+	}
+}
+
+func TestWasmPlugin_shadow(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "http.wasm")
+	err := os.WriteFile(file, echoWasm, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = loadWasmFile(file); err == nil {
+		t.Error("a plugin named after the http module should be rejected")
+	}
+}
+
+func Test_doWasmHttp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large" {
+			_, _ = w.Write(make([]byte, wasmHttpMaxBody+1))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	defer config.SetupConfig(config.WasmHttpAllow, "")
+
+	config.SetupConfig(config.WasmHttpAllow, "")
+	if _, err := doWasmHttp(context.Background(), wasmHttpReq{Url: srv.URL}); err == nil {
+		t.Error("http_request should be disabled by default")
+	}
+	config.SetupConfig(config.WasmHttpAllow, "127.0.0.1")
+	res, err := doWasmHttp(context.Background(), wasmHttpReq{Url: srv.URL})
+	if err != nil || res.Status != http.StatusOK || res.Body != "ok" {
+		t.Errorf("doWasmHttp() = %v, %v", res, err)
+	}
+	if _, err = doWasmHttp(context.Background(), wasmHttpReq{Url: srv.URL + "/large"}); err == nil {
+		t.Error("a body over the limit should fail")
+	}
+}
@@ -0,0 +1,143 @@
+// Package jwt the JSON web tokens signed by the scripts and the OIDC bearers of the API.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var hmacAlgs = map[string]func() hash.Hash{
+	"HS256": sha256.New,
+	"HS384": sha512.New384,
+	"HS512": sha512.New,
+}
+
+var (
+	ErrMalformed = errors.New("malformed jwt")
+	ErrSignature = errors.New("invalid jwt signature")
+	ErrExpired   = errors.New("jwt has expired")
+	ErrNotBefore = errors.New("jwt is not valid yet")
+)
+
+type Header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// Token a token split into its parts, it's not trusted before Verify.
+type Token struct {
+	Header    Header
+	Claims    map[string]any
+	signed    string // the encoded header and claims.
+	signature []byte
+}
+
+// Parse decode the token without verifying it.
+func Parse(raw string) (*Token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	t := &Token{signed: parts[0] + "." + parts[1]}
+	if decodeSegment(parts[0], &t.Header) != nil || decodeSegment(parts[1], &t.Claims) != nil {
+		return nil, ErrMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	t.signature = sig
+	return t, nil
+}
+
+// Verify the signature with the key of the alg of the token: a []byte secret of HS256/HS384/HS512,
+// an *rsa.PublicKey of RS256 or an *ecdsa.PublicKey of ES256. a key of another type is not accepted.
+func (t *Token) Verify(key any) error {
+	if h, ok := hmacAlgs[t.Header.Alg]; ok {
+		secret, ok := key.([]byte)
+		if ok == false || hmac.Equal(t.signature, signature(h, secret, t.signed)) == false {
+			return ErrSignature
+		}
+		return nil
+	}
+	digest := sha256.Sum256([]byte(t.signed))
+	switch t.Header.Alg {
+	case "RS256":
+		k, ok := key.(*rsa.PublicKey)
+		if ok == false || rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], t.signature) != nil {
+			return ErrSignature
+		}
+		return nil
+	case "ES256":
+		k, ok := key.(*ecdsa.PublicKey)
+		if ok == false || len(t.signature) != 64 {
+			return ErrSignature
+		}
+		r, s := new(big.Int).SetBytes(t.signature[:32]), new(big.Int).SetBytes(t.signature[32:])
+		if ecdsa.Verify(k, digest[:], r, s) == false {
+			return ErrSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported jwt algorithm: %s", t.Header.Alg)
+}
+
+// CheckTime the exp and nbf claims if there are, the clocks may differ by skew.
+func (t *Token) CheckTime(now time.Time, skew time.Duration) error {
+	if exp, ok := t.Claims["exp"].(float64); ok && now.Add(-skew).Unix() >= int64(exp) {
+		return ErrExpired
+	}
+	if nbf, ok := t.Claims["nbf"].(float64); ok && now.Add(skew).Unix() < int64(nbf) {
+		return ErrNotBefore
+	}
+	return nil
+}
+
+// Sign the claims with a HMAC secret, alg defaults to HS256.
+func Sign(claims map[string]any, secret []byte, alg string) (string, error) {
+	if alg == "" {
+		alg = "HS256"
+	}
+	h, ok := hmacAlgs[alg]
+	if ok == false {
+		return "", fmt.Errorf("unsupported jwt algorithm: %s", alg)
+	}
+	header, err := json.Marshal(Header{Alg: alg, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	return signed + "." + enc.EncodeToString(signature(h, secret, signed)), nil
+}
+
+func signature(h func() hash.Hash, secret []byte, signed string) []byte {
+	mac := hmac.New(h, secret)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(seg string, v any) error {
+	buffer, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buffer, v)
+}
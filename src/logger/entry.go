@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type field struct {
+	key   string
+	value any
+}
+
+// Entry a logger with key/value fields, e.g. logger.With("jobId", id).Error(err).
+type Entry struct {
+	fields []field
+}
+
+// With start an entry with the key/value pairs.
+func With(kv ...any) *Entry {
+	return (&Entry{}).With(kv...)
+}
+
+// With return a new entry with the key/value pairs added, an odd value is dropped.
+func (e *Entry) With(kv ...any) *Entry {
+	fields := make([]field, len(e.fields), len(e.fields)+len(kv)/2)
+	copy(fields, e.fields)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fields = append(fields, field{key: fmt.Sprint(kv[i]), value: kv[i+1]})
+	}
+	return &Entry{fields: fields}
+}
+
+func (e *Entry) Debug(v ...any) {
+	output(DEBUG, e.fields, v)
+}
+func (e *Entry) Info(v ...any) {
+	output(INFO, e.fields, v)
+}
+func (e *Entry) Warn(v ...any) {
+	output(WARN, e.fields, v)
+}
+func (e *Entry) Error(v ...any) {
+	output(ERROR, e.fields, v)
+}
+func (e *Entry) Fatal(v ...any) {
+	output(FATAL, e.fields, v)
+}
+
+func format(f string, t time.Time, level string, caller string, msg string, fields []field) []byte {
+	if f == JsonFormat {
+		mp := make(map[string]any, len(fields)+4)
+		for _, fd := range fields {
+			mp[fd.key] = jsonValue(fd.value)
+		}
+		mp["time"] = t.Format(time.RFC3339Nano)
+		mp["level"] = level
+		mp["caller"] = caller
+		mp["msg"] = msg
+		buffer, _ := json.Marshal(mp)
+		return append(buffer, '\n')
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s [%s][%s] %s", t.Format("2006/01/02 15:04:05"), level, caller, msg))
+	for _, fd := range fields {
+		b.WriteString(fmt.Sprintf(" %s=%v", fd.key, fd.value))
+	}
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+// jsonValue errors have no exported fields, they are written as their messages.
+func jsonValue(v any) any {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return v
+}
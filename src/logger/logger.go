@@ -3,17 +3,14 @@ package logger
 import (
 	"fmt"
 	"github.com/mitchellh/go-homedir"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
-)
-
-var (
-	mu      sync.Mutex
-	logger  *log.Logger
-	_logger *log.Logger
+	"sync/atomic"
+	"time"
 )
 
 // prefix
@@ -25,55 +22,196 @@ const (
 	FATAL = "FATAL"
 )
 
+var levels = map[string]int{DEBUG: 0, INFO: 1, WARN: 2, ERROR: 3, FATAL: 4}
+
+// output formats.
+const (
+	TextFormat = "text"
+	JsonFormat = "json"
+)
+
+// JobIdField entries with this field are also written into the log file of the job.
+const JobIdField = "jobId"
+
+// Config of the logger, zero values are the defaults.
+type Config struct {
+	Level  string // minimum level, DEBUG by default.
+	Format string // text or json.
+	Dir    string // ~/.traitor/log by default.
+	// MaxSize the file is rotated when it's larger than MaxSize MB, it's also rotated every day.
+	MaxSize    int64
+	MaxAge     time.Duration // rotated files older than MaxAge are removed.
+	MaxBackups int           // how many rotated files are kept.
+	JobFiles   bool          // write the entries of each job into <Dir>/jobs/<jobId>.log too.
+	Quiet      bool          // don't print to stdout.
+}
+
+const (
+	defaultMaxSize    = 100
+	defaultMaxAge     = time.Hour * 24 * 30
+	defaultMaxBackups = 10
+	logFileName       = "traitorLog.txt"
+	jobDir            = "jobs"
+	maxJobFiles       = 64 // the job files open at once, the least recently written one is closed first.
+)
+
+var (
+	mu       sync.Mutex
+	config   Config
+	minLevel int32 // read without mu, see output.
+	file     *rotateFile
+	stdout   io.Writer = os.Stdout
+	jobFiles           = make(map[string]*jobFile)
+	jobUses  int64     // the counter of the writes into the job files.
+)
+
+// jobFile the log file of a job, lastUse orders the files to close.
+type jobFile struct {
+	*rotateFile
+	lastUse int64
+}
+
 func init() {
-	dir, err := homedir.Dir()
+	err := Setup(Config{})
 	if err != nil {
 		panic(err)
 	}
-	var path = fmt.Sprintf("%s/.traitor/log", dir)
-	err = os.MkdirAll(path, 0744)
+}
+
+// Setup replace the config, the log file is reopened.
+func Setup(c Config) error {
+	level := strings.ToUpper(c.Level)
+	if level == "" {
+		level = DEBUG
+	}
+	l, ok := levels[level]
+	if ok == false {
+		return fmt.Errorf("unknown log level: %s", c.Level)
+	}
+	switch c.Format {
+	case "":
+		c.Format = TextFormat
+	case TextFormat, JsonFormat:
+	default:
+		return fmt.Errorf("unknown log format: %s", c.Format)
+	}
+	if c.Dir == "" {
+		dir, err := homedir.Dir()
+		if err != nil {
+			return err
+		}
+		c.Dir = fmt.Sprintf("%s/.traitor/log", dir)
+	}
+	if c.MaxSize <= 0 {
+		c.MaxSize = defaultMaxSize
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = defaultMaxAge
+	}
+	if c.MaxBackups <= 0 {
+		c.MaxBackups = defaultMaxBackups
+	}
+	f, err := openRotateFile(filepath.Join(c.Dir, logFileName), c)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	var file = path + "/traitorLog.txt"
 
-	logFile, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0766)
-	if err != nil {
-		panic(err)
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		_ = file.Close()
+	}
+	for k, jf := range jobFiles {
+		_ = jf.Close()
+		delete(jobFiles, k)
+	}
+	config = c
+	atomic.StoreInt32(&minLevel, int32(l))
+	file = f
+	if c.Quiet {
+		stdout = io.Discard
+	} else {
+		stdout = os.Stdout
 	}
-	logger = log.New(logFile, "", log.LstdFlags|log.Lshortfile|log.LUTC)
-	_logger = log.New(os.Stdout, "", log.LstdFlags|log.Lshortfile|log.LUTC)
+	return nil
 }
+
 func Debug(v ...any) {
-	_log(DEBUG, v)
+	output(DEBUG, nil, v)
 }
 func Error(v ...any) {
-	_log(ERROR, v)
+	output(ERROR, nil, v)
 }
 func Info(v ...any) {
-	_log(INFO, v)
+	output(INFO, nil, v)
 }
 func Warn(v ...any) {
-	_log(WARN, v)
+	output(WARN, nil, v)
 }
 func Fatal(v ...any) {
-	_log(FATAL, v)
+	output(FATAL, nil, v)
 }
-func _log(prefix string, v any) {
+
+// output the caller is 3 frames above, the exported func and Entry's methods call it directly.
+func output(level string, fields []field, v []any) {
+	if int32(levels[level]) < atomic.LoadInt32(&minLevel) {
+		return
+	}
+	caller := ""
+	if _, f, line, ok := runtime.Caller(2); ok {
+		caller = fmt.Sprintf("%s:%d", filepath.Base(f), line)
+	}
+	msg := strings.TrimSuffix(fmt.Sprintln(v...), "\n")
+
 	mu.Lock()
 	defer mu.Unlock()
-	setPrefix(prefix)
-	logger.Println(v)
-	_logger.Println(v)
+	line := format(config.Format, time.Now().UTC(), level, caller, msg, fields)
+	_, _ = file.Write(line)
+	_, _ = stdout.Write(line)
+	if config.JobFiles {
+		writeJobFile(fields, line)
+	}
 }
-func setPrefix(logType string) {
-	_, file, line, ok := runtime.Caller(3)
-	var logPrefix string
-	if ok {
-		logPrefix = fmt.Sprintf("[%s][%s:%d]", logType, filepath.Base(file), line)
-	} else {
-		logPrefix = fmt.Sprintf("[%s]", logType)
+
+// writeJobFile caller must hold mu.
+func writeJobFile(fields []field, line []byte) {
+	jobId := ""
+	for _, f := range fields {
+		if f.key == JobIdField {
+			jobId = fmt.Sprint(f.value)
+		}
+	}
+	if jobId == "" || strings.ContainsAny(jobId, `/\`) || jobId == "." || jobId == ".." {
+		return
+	}
+	jf, ok := jobFiles[jobId]
+	if ok == false {
+		if len(jobFiles) >= maxJobFiles {
+			closeLeastUsed()
+		}
+		f, err := openRotateFile(filepath.Join(config.Dir, jobDir, jobId+".log"), config)
+		if err != nil {
+			_, _ = file.Write([]byte("open job log file error:" + err.Error() + "\n"))
+			return
+		}
+		jf = &jobFile{rotateFile: f}
+		jobFiles[jobId] = jf
+	}
+	jobUses++
+	jf.lastUse = jobUses
+	_, _ = jf.Write(line)
+}
+
+// closeLeastUsed close the job file written the longest ago. caller must hold mu.
+func closeLeastUsed() {
+	oldest := ""
+	for id, jf := range jobFiles {
+		if oldest == "" || jf.lastUse < jobFiles[oldest].lastUse {
+			oldest = id
+		}
+	}
+	if oldest != "" {
+		_ = jobFiles[oldest].Close()
+		delete(jobFiles, oldest)
 	}
-	logger.SetPrefix(logPrefix)
-	_logger.SetPrefix(logPrefix)
 }
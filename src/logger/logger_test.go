@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	dir := t.TempDir()
+	err := Setup(Config{Level: "warn", Format: JsonFormat, Dir: dir, JobFiles: true, Quiet: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = Setup(Config{})
+	}()
+	Info("dropped")
+	With(JobIdField, "job-1", "runId", "run-1").Warn("slow", 3)
+
+	buffer, _ := os.ReadFile(filepath.Join(dir, logFileName))
+	lines := strings.Split(strings.TrimSpace(string(buffer)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("want 1 line, got %q", buffer)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["level"] != WARN || entry["msg"] != "slow 3" || entry["runId"] != "run-1" ||
+		strings.HasPrefix(entry["caller"].(string), "logger_test.go") == false {
+		t.Errorf("unexpected entry %v", entry)
+	}
+	job, _ := os.ReadFile(filepath.Join(dir, jobDir, "job-1.log"))
+	if string(job) != lines[0]+"\n" {
+		t.Errorf("unexpected job log %q", job)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.txt")
+	r, err := openRotateFile(path, Config{MaxSize: 1, MaxAge: defaultMaxAge, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	line := []byte(strings.Repeat("x", 400*1024) + "\n")
+	for i := 0; i < 12; i++ {
+		_, _ = r.Write(line)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "test-*.txt"))
+	if len(backups) != 2 {
+		t.Errorf("want 2 backups, got %d", len(backups))
+	}
+	info, _ := os.Stat(path)
+	if info.Size() > 1024*1024 {
+		t.Errorf("file should be rotated, size %d", info.Size())
+	}
+}
+
+func TestJobFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := Setup(Config{Dir: dir, JobFiles: true, Quiet: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = Setup(Config{})
+	}()
+	for i := 0; i <= maxJobFiles; i++ {
+		With(JobIdField, fmt.Sprintf("job-%d", i)).Info("run")
+	}
+	mu.Lock()
+	_, open := jobFiles["job-0"]
+	n := len(jobFiles)
+	mu.Unlock()
+	if n != maxJobFiles || open {
+		t.Errorf("want %d open files without the least recently used one, got %d, job-0 open %v", maxJobFiles, n, open)
+	}
+	// the closed file is opened again.
+	With(JobIdField, "job-0").Info("again")
+	job, _ := os.ReadFile(filepath.Join(dir, jobDir, "job-0.log"))
+	if strings.Count(string(job), "\n") != 2 {
+		t.Errorf("unexpected job log %q", job)
+	}
+}
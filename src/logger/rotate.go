@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000000000"
+
+// rotateFile a log file rotated by size and day, the rotated files are kept as <name>-<time><ext>.
+type rotateFile struct {
+	path     string
+	config   Config
+	f        *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func openRotateFile(path string, c Config) (*rotateFile, error) {
+	r := &rotateFile{path: path, config: c}
+	err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotateFile) open() error {
+	err := os.MkdirAll(filepath.Dir(r.path), 0744)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.f = f
+	r.size = info.Size()
+	r.openedAt = info.ModTime()
+	if r.size == 0 {
+		r.openedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *rotateFile) Write(p []byte) (int, error) {
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	now := time.Now()
+	if r.size > 0 && (r.size+int64(len(p)) > r.config.MaxSize*1024*1024 || sameDay(r.openedAt, now) == false) {
+		_ = r.rotate(now)
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func sameDay(a time.Time, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func (r *rotateFile) rotate(now time.Time) error {
+	_ = r.f.Close()
+	r.f = nil
+	ext := filepath.Ext(r.path)
+	backup := strings.TrimSuffix(r.path, ext) + "-" + now.Format(backupTimeFormat) + ext
+	err := os.Rename(r.path, backup)
+	if err != nil {
+		return err
+	}
+	r.cleanup(now)
+	return r.open()
+}
+
+// cleanup remove the rotated files beyond MaxBackups or older than MaxAge.
+func (r *rotateFile) cleanup(now time.Time) {
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(r.path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return
+	}
+	backups := make([]string, 0, len(matches))
+	for _, m := range matches {
+		// skip the files which only look like backups, e.g. of another job.
+		if _, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(m, prefix), ext)); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups))) // newest first, the time format sorts.
+	for i, b := range backups {
+		info, err := os.Stat(b)
+		if err != nil {
+			continue
+		}
+		if i >= r.config.MaxBackups || now.Sub(info.ModTime()) > r.config.MaxAge {
+			_ = os.Remove(b)
+		}
+	}
+}
+
+func (r *rotateFile) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
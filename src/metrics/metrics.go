@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "traitor"
+
+// runs.
+var (
+	RunsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "runs_total",
+		Help:      "Finished runs by namespace, task type and outcome.",
+	}, []string{"namespace", "task", "state"})
+	RunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "run_duration_seconds",
+		Help:      "Duration of the runs by namespace and task type.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10), // 10ms to ~43min.
+	}, []string{"namespace", "task"})
+	RunningJobs = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "running_jobs",
+		Help:      "Goroutines executing jobs.",
+	})
+	PendingTasks = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pending_tasks",
+		Help:      "Tasks waiting in the time wheel.",
+	})
+)
+
+// api.
+var RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "Latency of the HTTP API by method, route and status.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "path", "status"})
+
+// cluster mode.
+var (
+	ClusterNodes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cluster_nodes",
+		Help:      "Nodes in the consistent hash of the cluster.",
+	})
+	HeartbeatFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "heartbeat_failures_total",
+		Help:      "Heartbeats failed to be written into redis.",
+	})
+	PubSubLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "pubsub_lag_seconds",
+		Help:      "Time from publishing a cluster event to handling it.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 8), // 0.5ms to ~8s.
+	})
+)
+
+// StoreStats the stats of the embedded store.
+type StoreStats struct {
+	Keys     int
+	TtlKeys  int
+	AofQueue int
+}
+
+// RegisterStore export the stats of the embedded store, it's only used in standalone mode.
+func RegisterStore(stats func() StoreStats) {
+	gauge := func(name string, help string, value func(StoreStats) int) {
+		prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "store",
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return float64(value(stats()))
+		}))
+	}
+	gauge("keys", "Keys in the embedded store.", func(s StoreStats) int { return s.Keys })
+	gauge("ttl_keys", "Keys with a ttl in the embedded store.", func(s StoreStats) int { return s.TtlKeys })
+	gauge("aof_queue", "Commands waiting to be appended into the AOF file.", func(s StoreStats) int { return s.AofQueue })
+}
+
+// Handler serve the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,109 @@
+// Package rpcplugin is used to write out-of-process plugins.
+//
+// A plugin is an executable placed in the plugin directory with the ".plugin" extension.
+// The server launches it and talks JSON-RPC over its stdin/stdout, so the plugin
+// could be built with any toolchain. stdout is reserved for the protocol,
+// write logs to stderr, which is forwarded to the server's log.
+//
+//	func main() {
+//		_ = rpcplugin.Serve("greet", map[string]rpcplugin.Func{
+//			"hello": func(args []json.RawMessage) (any, error) {
+//				var name string
+//				_ = json.Unmarshal(args[0], &name)
+//				return "hello " + name, nil
+//			},
+//		})
+//	}
+//
+// Scripts call it with require('greet').hello('traitor').
+package rpcplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"sort"
+)
+
+const (
+	ServiceName    = "Plugin"
+	DescribeMethod = ServiceName + ".Describe"
+	CallMethod     = ServiceName + ".Call"
+)
+
+type DescribeArgs struct {
+}
+
+type DescribeReply struct {
+	Name      string   `json:"name"`
+	Functions []string `json:"functions"`
+}
+
+// CallArgs each argument is the json of the javascript value.
+type CallArgs struct {
+	Function string            `json:"function"`
+	Args     []json.RawMessage `json:"args"`
+}
+
+type CallReply struct {
+	Result json.RawMessage `json:"result"`
+}
+
+// Func a function exported to scripts. the result is marshalled to json.
+type Func func(args []json.RawMessage) (any, error)
+
+type service struct {
+	name  string
+	funcs map[string]Func
+}
+
+func (s *service) Describe(_ DescribeArgs, reply *DescribeReply) error {
+	reply.Name = s.name
+	reply.Functions = make([]string, 0, len(s.funcs))
+	for name := range s.funcs {
+		reply.Functions = append(reply.Functions, name)
+	}
+	sort.Strings(reply.Functions)
+	return nil
+}
+
+func (s *service) Call(args CallArgs, reply *CallReply) error {
+	fn, ok := s.funcs[args.Function]
+	if ok == false {
+		return fmt.Errorf("function not found: %s", args.Function)
+	}
+	res, err := fn(args.Args)
+	if err != nil {
+		return err
+	}
+	reply.Result, err = json.Marshal(res)
+	return err
+}
+
+type stdio struct {
+}
+
+func (stdio) Read(p []byte) (int, error) {
+	return os.Stdin.Read(p)
+}
+
+func (stdio) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+func (stdio) Close() error {
+	return os.Stdin.Close()
+}
+
+// Serve the module on stdin/stdout, blocks until the server closes the pipe.
+func Serve(name string, funcs map[string]Func) error {
+	server := rpc.NewServer()
+	err := server.RegisterName(ServiceName, &service{name: name, funcs: funcs})
+	if err != nil {
+		return err
+	}
+	server.ServeCodec(jsonrpc.NewServerCodec(stdio{}))
+	return nil
+}
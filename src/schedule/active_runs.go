@@ -0,0 +1,144 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"sort"
+	"sync"
+	"traitor/dao/model"
+	"traitor/logger"
+)
+
+var ErrRunNotActive = errors.New("run is not active")
+
+// runTracker the registry of the executions in flight.
+type runTracker interface {
+	add(run model.RunEntity, cancel context.CancelFunc)
+	remove(runId string)
+	list() ([]model.RunEntity, error)
+	cancel(runId string) error
+}
+
+type activeRun struct {
+	run    model.RunEntity
+	cancel context.CancelFunc
+}
+
+// localTracker the runs executed by this process.
+type localTracker struct {
+	mu   sync.Mutex
+	runs map[string]*activeRun
+}
+
+func makeLocalTracker() *localTracker {
+	return &localTracker{runs: make(map[string]*activeRun)}
+}
+
+func (t *localTracker) add(run model.RunEntity, cancel context.CancelFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.runs[run.RunId] = &activeRun{run: run, cancel: cancel}
+}
+
+func (t *localTracker) remove(runId string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.runs, runId)
+}
+
+// list the runs, oldest first.
+func (t *localTracker) list() ([]model.RunEntity, error) {
+	t.mu.Lock()
+	res := make([]model.RunEntity, 0, len(t.runs))
+	for _, r := range t.runs {
+		res = append(res, r.run)
+	}
+	t.mu.Unlock()
+	sortRuns(res)
+	return res, nil
+}
+
+func (t *localTracker) cancel(runId string) error {
+	t.mu.Lock()
+	r, ok := t.runs[runId]
+	t.mu.Unlock()
+	if ok == false {
+		return ErrRunNotActive
+	}
+	r.cancel()
+	return nil
+}
+
+func sortRuns(runs []model.RunEntity) {
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartAt.Before(*runs[j].StartAt)
+	})
+}
+
+const activeRunsKey = "traitor_active_runs:"
+
+// redisTracker share the registry through redis, a run is cancelled by the node executing it.
+type redisTracker struct {
+	*localTracker
+	client *redis.Client
+	key    string
+	// notify publish the cancel to the other nodes.
+	notify func(content string) error
+}
+
+func (t *redisTracker) add(run model.RunEntity, cancel context.CancelFunc) {
+	t.localTracker.add(run, cancel)
+	buffer, _ := json.Marshal(run)
+	err := t.client.HSet(context.TODO(), t.key, run.RunId, buffer).Err()
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+func (t *redisTracker) remove(runId string) {
+	t.localTracker.remove(runId)
+	err := t.client.HDel(context.TODO(), t.key, runId).Err()
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+// list the runs of the nodes alive, the runs left by dead nodes are dropped.
+func (t *redisTracker) list() ([]model.RunEntity, error) {
+	ctx := context.TODO()
+	mp, err := t.client.HGetAll(ctx, t.key).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.RunEntity, 0, len(mp))
+	for runId, v := range mp {
+		var run model.RunEntity
+		if json.Unmarshal([]byte(v), &run) != nil {
+			continue
+		}
+		if n, err := t.client.Exists(ctx, run.NodeId).Result(); err == nil && n == 0 {
+			t.client.HDel(ctx, t.key, runId)
+			continue
+		}
+		res = append(res, run)
+	}
+	sortRuns(res)
+	return res, nil
+}
+
+func (t *redisTracker) cancel(runId string) error {
+	if t.localTracker.cancel(runId) == nil {
+		return nil
+	}
+	ok, err := t.client.HExists(context.TODO(), t.key, runId).Result()
+	if err != nil {
+		return err
+	}
+	if ok == false {
+		return ErrRunNotActive
+	}
+	return t.notify(fmt.Sprintf(runCancel, runId))
+}
@@ -0,0 +1,37 @@
+package schedule
+
+import (
+	"context"
+	"github.com/dop251/goja"
+	"testing"
+	"time"
+	"traitor/dao/model"
+)
+
+func Test_cancelScript(t *testing.T) {
+	s := schedule{vmPool: makeVmPool(1), programs: makeProgramCache(), active: makeLocalTracker(), output: makeLocalHub()}
+	defer s.vmPool.stop()
+	s.programs.put("job", 1, goja.MustCompile("job", `while (true) {}`, false))
+	now := time.Now()
+	run := model.RunEntity{RunId: "run-1", JobId: "job", StartAt: &now}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.active.add(run, cancel)
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		if runs, _ := s.ActiveRuns(); len(runs) != 1 {
+			t.Errorf("want 1 active run, got %d", len(runs))
+		}
+		if err := s.CancelRun("run-1"); err != nil {
+			t.Error(err)
+		}
+	}()
+	err := s.runScript(ctx, &model.JobEntity{JobId: "job", Revision: 1}, &run)
+	if _, ok := err.(*goja.InterruptedError); ok == false {
+		t.Fatalf("the script should be interrupted, got %v", err)
+	}
+	s.active.remove("run-1")
+	if err := s.CancelRun("run-1"); err != ErrRunNotActive {
+		t.Errorf("want ErrRunNotActive, got %v", err)
+	}
+}
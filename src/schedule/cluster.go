@@ -0,0 +1,96 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"traitor/consistenthash"
+	"traitor/dao/model"
+)
+
+const (
+	ModeStandalone = "standalone"
+	ModeMulti      = "multi"
+)
+
+// Ready the readiness checks of the node, a nil error means the check passed.
+func (s *schedule) Ready() map[string]error {
+	return map[string]error{
+		"dao":       s.dao.Ping(),
+		"timeWheel": s.timeWheel.check(),
+	}
+}
+
+func (s *StandaloneSchedule) Cluster() (model.ClusterInfo, error) {
+	jbs, err := s.runnableJobs()
+	if err != nil {
+		return model.ClusterInfo{}, err
+	}
+	return model.ClusterInfo{
+		Mode:  ModeStandalone,
+		Nodes: []model.NodeInfo{{NodeId: ModeStandalone, LastHeartbeat: time.Now(), Jobs: len(jbs), Self: true}},
+	}, nil
+}
+
+func (s *MultiNodeSchedule) Ready() map[string]error {
+	checks := s.schedule.Ready()
+	checks["cluster"] = nil
+	if s.state.Load() != keepAlive {
+		checks["cluster"] = errors.New("node list is not synced")
+	}
+	return checks
+}
+
+func (s *MultiNodeSchedule) Cluster() (model.ClusterInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	info := model.ClusterInfo{Mode: ModeMulti, Cluster: s.cluster, Nodes: make([]model.NodeInfo, 0)}
+	keys, err := s.scanNodes(ctx)
+	if err != nil || len(keys) == 0 {
+		return info, err
+	}
+	beats, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return info, err
+	}
+	jbs, err := s.runnableJobs()
+	if err != nil {
+		return info, err
+	}
+	owned := make(map[string]int)
+	if m, ok := s.consistentMap.Load().(*consistenthash.Map); ok {
+		for _, j := range jbs {
+			owned[m.Get(JobKey(j.Namespace, j.JobId))]++
+		}
+	}
+	for i, key := range keys {
+		node := model.NodeInfo{NodeId: key, Jobs: owned[key], Self: key == s.NodeId}
+		if v, ok := beats[i].(string); ok {
+			if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+				node.LastHeartbeat = time.UnixMilli(ms)
+			}
+		}
+		info.Nodes = append(info.Nodes, node)
+	}
+	return info, nil
+}
+
+// scanNodes the keys of the nodes sending heartbeat.
+func (s *MultiNodeSchedule) scanNodes(ctx context.Context) ([]string, error) {
+	var cursor uint64 = 0
+	list := make([]string, 0)
+	matchStr := fmt.Sprintf("%s*", nodeId+s.cluster)
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, matchStr, 1000).Result()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, keys...)
+		if next == 0 {
+			return list, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,167 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"traitor/config"
+	"traitor/dao/model"
+)
+
+// maxOutput the max bytes of stdout or stderr kept in the run record.
+const maxOutput = 64 * 1024
+
+// limitedBuffer keeps the first maxOutput bytes and drops the rest.
+type limitedBuffer struct {
+	buf bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := maxOutput - b.buf.Len(); remain > 0 {
+		if len(p) > remain {
+			b.buf.Write(p[:remain])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// the environment variables a command job could not set, they change what the allowed executable runs.
+var (
+	deniedEnvPrefixes = []string{"LD_", "DYLD_"}
+	deniedEnv         = map[string]bool{"BASH_ENV": true, "ENV": true, "GCONV_PATH": true, "PATH": true}
+)
+
+// inheritedEnv the only variables of the server a command gets, the others may hold its secrets like the admin token.
+var inheritedEnv = []string{"PATH", "HOME"}
+
+// commandEnv the environment of the command, the inherited variables and the ones of the job.
+func commandEnv(c *model.Command) []string {
+	env := make([]string, 0, len(inheritedEnv)+len(c.Env))
+	for _, key := range inheritedEnv {
+		if v, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		}
+	}
+	return append(env, c.Env...)
+}
+
+// CheckCommand verify the command job is enabled and the executable is allowed.
+func CheckCommand(c *model.Command) error {
+	_, err := commandPath(c)
+	return err
+}
+
+// commandPath the allowed executable of the command.
+func commandPath(c *model.Command) (string, error) {
+	if config.GetConfig(config.CommandEnable) != "true" {
+		return "", errors.New("command job is disabled")
+	}
+	if c == nil || len(c.Args) == 0 || c.Args[0] == "" {
+		return "", errors.New("command args cannot be empty")
+	}
+	if err := checkEnv(c.Env); err != nil {
+		return "", err
+	}
+	name := c.Args[0]
+	// a relative path is run from the dir of the command, not from the one of the server.
+	if c.Dir != "" && filepath.Base(name) != name && filepath.IsAbs(name) == false {
+		name = filepath.Join(c.Dir, name)
+	}
+	path, err := lookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("executable not found: %s", c.Args[0])
+	}
+	for _, allowed := range config.GetConfigList(config.CommandAllow) {
+		if allowed == "*" {
+			return path, nil
+		}
+		if p, err := lookPath(allowed); err == nil && p == path {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("executable is not allowed: %s", c.Args[0])
+}
+
+// lookPath the absolute path of the executable.
+func lookPath(name string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+func checkEnv(env []string) error {
+	for _, kv := range env {
+		key, _, ok := strings.Cut(kv, "=")
+		if ok == false || key == "" {
+			return fmt.Errorf("env should be KEY=VALUE: %s", kv)
+		}
+		if deniedEnv[key] {
+			return fmt.Errorf("env is not allowed: %s", key)
+		}
+		for _, prefix := range deniedEnvPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				return fmt.Errorf("env is not allowed: %s", key)
+			}
+		}
+	}
+	return nil
+}
+
+// runCommand execute the command, the output and exit code are written into the run.
+// the process and the ones it started are killed when ctx is done.
+func (s *schedule) runCommand(ctx context.Context, c *model.Command, run *model.RunEntity) error {
+	path, err := commandPath(c)
+	if err != nil {
+		run.ExitCode = -1
+		return err
+	}
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.Timeout)*time.Second)
+		defer cancel()
+	}
+	var stdout, stderr limitedBuffer
+	cmd := exec.Command(path, c.Args[1:]...)
+	cmd.Args[0] = c.Args[0]
+	cmd.Dir = c.Dir
+	cmd.Env = commandEnv(c)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err = startGroup(cmd); err != nil {
+		run.ExitCode = -1
+		return err
+	}
+	// the output is read until every process holding it exits, kill them all, not only the command.
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			killGroup(cmd)
+		case <-done:
+		}
+	}()
+	err = cmd.Wait()
+	close(done)
+	run.Stdout = stdout.buf.String()
+	run.Stderr = stderr.buf.String()
+	if cmd.ProcessState != nil {
+		run.ExitCode = cmd.ProcessState.ExitCode()
+	} else {
+		run.ExitCode = -1
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.New("command timeout")
+	}
+	return err
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package schedule
+
+import "os/exec"
+
+func startGroup(cmd *exec.Cmd) error {
+	return cmd.Start()
+}
+
+// killGroup only the command is killed, its children keep running.
+func killGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
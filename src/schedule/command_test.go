@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"traitor/config"
+	"traitor/dao/model"
+)
+
+func Test_runCommand(t *testing.T) {
+	config.SetupConfig(config.CommandEnable, "true")
+	config.SetupConfig(config.CommandAllow, "sh")
+	defer config.SetupConfig(config.CommandEnable, "false")
+
+	var s = makeStandalone(nil)
+	var run model.RunEntity
+	err := s.runCommand(context.Background(), &model.Command{Args: []string{"sh", "-c", "echo $GREETING; echo oops >&2; exit 3"},
+		Env: []string{"GREETING=hello"}}, &run)
+	if err == nil {
+		t.Error("non-zero exit should be an error")
+	}
+	if run.ExitCode != 3 || strings.TrimSpace(run.Stdout) != "hello" || strings.TrimSpace(run.Stderr) != "oops" {
+		t.Errorf("unexpected run result: %+v", run)
+	}
+
+	// the secrets in the environment of the server are not passed on.
+	t.Setenv("TRAITOR_ADMIN_TOKEN", "secret")
+	err = s.runCommand(context.Background(), &model.Command{Args: []string{"sh", "-c", "echo \"$TRAITOR_ADMIN_TOKEN$GREETING\""},
+		Env: []string{"GREETING=hello"}}, &run)
+	if err != nil || strings.TrimSpace(run.Stdout) != "hello" {
+		t.Errorf("the command saw %q %v, want only its own env", run.Stdout, err)
+	}
+
+	// the sleep is a child of the shell holding the output open, it's killed too.
+	start := time.Now()
+	err = s.runCommand(context.Background(), &model.Command{Args: []string{"sh", "-c", "sleep 5; echo done"}, Timeout: 1}, &run)
+	if err == nil || err.Error() != "command timeout" {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("the command ran %v after its timeout", elapsed)
+	}
+
+	err = s.runCommand(context.Background(), &model.Command{Args: []string{"sh", "-c", "true"}, Env: []string{"LD_PRELOAD=/tmp/x.so"}}, &run)
+	if err == nil {
+		t.Error("loader env should be rejected")
+	}
+
+	err = s.runCommand(context.Background(), &model.Command{Args: []string{"ls"}}, &run)
+	if err == nil {
+		t.Error("executable not in the allow list should be rejected")
+	}
+
+	// a relative executable is looked up in the dir of the command.
+	dir := t.TempDir()
+	if err = os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\necho from dir\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	config.SetupConfig(config.CommandAllow, filepath.Join(dir, "run.sh"))
+	err = s.runCommand(context.Background(), &model.Command{Args: []string{"./run.sh"}, Dir: dir}, &run)
+	if err != nil || strings.TrimSpace(run.Stdout) != "from dir" {
+		t.Errorf("expected the script of the dir to run, got %v %+v", err, run)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package schedule
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startGroup run the command in a new process group, so its children could be killed with it.
+func startGroup(cmd *exec.Cmd) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd.Start()
+}
+
+// killGroup kill the command and every process it started.
+func killGroup(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
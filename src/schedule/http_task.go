@@ -0,0 +1,106 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"traitor/dao/model"
+	"traitor/tracing"
+)
+
+const (
+	defaultHttpTimeout = 30 // seconds.
+	maxResponse        = 4 * 1024
+)
+
+// CheckHttp verify the settings of a HTTP job.
+func CheckHttp(r *model.HttpRequest) error {
+	if r == nil {
+		return errors.New("http settings cannot be empty")
+	}
+	u, err := url.Parse(r.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid http url")
+	}
+	switch strings.ToUpper(r.Method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return fmt.Errorf("invalid http method: %s", r.Method)
+	}
+	for _, code := range r.ExpectStatus {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid expect status: %d", code)
+		}
+	}
+	return nil
+}
+
+func expectedStatus(r *model.HttpRequest, code int) bool {
+	if len(r.ExpectStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, c := range r.ExpectStatus {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// runHttp perform the request, the status and a snippet of the response are written into the run.
+// the request is aborted when ctx is done.
+func (s *schedule) runHttp(ctx context.Context, r *model.HttpRequest, run *model.RunEntity) (err error) {
+	err = CheckHttp(r)
+	if err != nil {
+		return err
+	}
+	method := strings.ToUpper(r.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "http "+method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPURLKey.String(r.Url)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	var body io.Reader
+	if r.Body != "" {
+		body = strings.NewReader(r.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.Url, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+	tracing.Inject(ctx, req.Header)
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = defaultHttpTimeout
+	}
+	client := http.Client{Timeout: time.Duration(timeout) * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(res.Body)
+	snippet, _ := io.ReadAll(io.LimitReader(res.Body, maxResponse))
+	run.StatusCode = res.StatusCode
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(res.StatusCode))
+	run.Response = string(snippet)
+	if expectedStatus(r, res.StatusCode) == false {
+		return fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"traitor/dao/model"
+	"traitor/tracing"
+)
+
+func Test_runHttp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("X-Token") != "abc" || string(body) != "ping" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("pong"))
+	}))
+	defer srv.Close()
+
+	var s = makeStandalone(nil)
+	var run model.RunEntity
+	req := &model.HttpRequest{Method: "post", Url: srv.URL, Headers: map[string]string{"X-Token": "abc"}, Body: "ping"}
+	err := s.runHttp(context.Background(), req, &run)
+	if err != nil || run.StatusCode != http.StatusAccepted || run.Response != "pong" {
+		t.Errorf("unexpected result: %v %+v", err, run)
+	}
+
+	req.ExpectStatus = []int{http.StatusOK}
+	err = s.runHttp(context.Background(), req, &run)
+	if err == nil {
+		t.Error("unexpected status should be an error")
+	}
+}
+
+func Test_runHttpTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	var s = makeStandalone(nil)
+	ctx, parent := tracing.Tracer().Start(context.Background(), "job.fire")
+	err := s.runHttp(ctx, &model.HttpRequest{Url: srv.URL}, &model.RunEntity{})
+	parent.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "http GET" || spans[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("unexpected spans %v", spans)
+	}
+	if strings.Contains(traceparent, spans[0].SpanContext().SpanID().String()) == false {
+		t.Errorf("the trace context should be propagated, got %q", traceparent)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"traitor/dao"
 	"traitor/dao/model"
 	"traitor/logger"
+	"traitor/metrics"
 )
 
 const (
@@ -32,7 +34,8 @@ type MultiNodeSchedule struct {
 	cancel        context.CancelFunc
 	consistentMap atomic.Value
 	cluster       string
-	state         uint8
+	state         atomic.Int32 // lostSync or keepAlive, read by the readiness check.
+	tracker       *redisTracker
 }
 
 func makeMultiNode(redisStr string, d dao.Dao, cluster string) *MultiNodeSchedule {
@@ -45,18 +48,27 @@ func makeMultiNode(redisStr string, d dao.Dao, cluster string) *MultiNodeSchedul
 		panic(err.Error())
 	}
 	uid := uuid.New()
+	id := nodeId + cluster + uid.String()
 	s := &MultiNodeSchedule{
-		schedule: schedule{timeWheel: makeTimeWheel(), dao: d},
+		schedule: makeSchedule(d, id),
 		client:   client,
-		NodeId:   nodeId + cluster + uid.String(),
+		NodeId:   id,
 		cluster:  cluster,
-		state:    lostSync,
 	}
+	s.output = makeRedisHub(client) // the stream may be served by another node.
+	s.tracker = &redisTracker{
+		localTracker: makeLocalTracker(),
+		client:       client,
+		key:          activeRunsKey + cluster,
+		notify:       s.notifyOtherNodes,
+	}
+	s.active = s.tracker
 	return s
 }
 
 func (s *MultiNodeSchedule) Close() {
 	s.timeWheel.stop()
+	s.vmPool.stop()
 	_ = s.client.Close()
 
 	if s.cancel != nil {
@@ -75,7 +87,7 @@ func (s *MultiNodeSchedule) Start(ctx context.Context) {
 
 // load jobs from db.
 func (s *MultiNodeSchedule) initJobs() {
-	jbs, err := s.dao.GetRunnableJobs()
+	jbs, err := s.runnableJobs()
 	if err != nil {
 		panic(err)
 	}
@@ -85,33 +97,22 @@ func (s *MultiNodeSchedule) initJobs() {
 }
 
 func (s *MultiNodeSchedule) syncNodeList(ctx context.Context) {
-	var cursor uint64 = 0
-	list := make([]string, 0)
-	matchStr := fmt.Sprintf("%s*", nodeId+s.cluster)
+	list, err := s.scanNodes(ctx)
 	errorFlag := false
-	for {
-		scan := s.client.Scan(ctx, cursor, matchStr, 1000)
-		keys, cursor, err := scan.Result()
-		if err != nil {
-			logger.Error("cannot sync node list from redis")
-			list = nil // clear slice.
-			errorFlag = true
-			s.state = lostSync
-			break
-		}
-		list = append(list, keys...)
-		if cursor == 0 {
-			break
-		}
+	if err != nil {
+		logger.Error("cannot sync node list from redis")
+		errorFlag = true
+		s.state.Store(lostSync)
 	}
 	// recover from the network connection error.
-	if errorFlag == false && s.state == lostSync {
-		s.state = keepAlive
+	if errorFlag == false && s.state.Load() == lostSync {
+		s.state.Store(keepAlive)
 		s.initJobs() // re-sync all job state from DB.
 	}
 
 	mp := consistenthash.New(replicaCount, nil)
 	mp.Add(list...)
+	metrics.ClusterNodes.Set(float64(mp.Len()))
 	s.consistentMap.Swap(mp)
 }
 func (s *MultiNodeSchedule) startSyncNodeList(ctx context.Context) {
@@ -140,9 +141,10 @@ func (s *MultiNodeSchedule) startHeartBeat(ctx context.Context) {
 			select {
 			case <-c:
 				{
-					res := s.client.SetEX(ctx, s.NodeId, "", time.Second*heartBeatTimeout)
+					res := s.client.SetEX(ctx, s.NodeId, time.Now().UnixMilli(), time.Second*heartBeatTimeout)
 					if res.Err() != nil {
 						// sth wrong with the redis connection.
+						metrics.HeartbeatFailures.Inc()
 						logger.Error(res.Err())
 					}
 				}
@@ -160,7 +162,7 @@ func (s *MultiNodeSchedule) handleAddJob(j *model.JobEntity) error {
 	if err != nil {
 		return err
 	}
-	err = s.notifyOtherNodes(fmt.Sprintf(jobAdd, j.JobId))
+	err = s.notifyOtherNodes(fmt.Sprintf(jobAdd, JobKey(j.Namespace, j.JobId)))
 	if err != nil {
 		return err
 	}
@@ -168,7 +170,8 @@ func (s *MultiNodeSchedule) handleAddJob(j *model.JobEntity) error {
 }
 
 func (s *MultiNodeSchedule) notifyOtherNodes(content string) error {
-	//notify other nodes.
+	//notify other nodes, the publish time is appended to measure the lag.
+	content = fmt.Sprintf("%s:%d", content, time.Now().UnixNano())
 	res := s.client.Publish(context.TODO(), redisChannel, content)
 	if res.Err() != nil {
 		return res.Err()
@@ -178,20 +181,21 @@ func (s *MultiNodeSchedule) notifyOtherNodes(content string) error {
 
 func (s *MultiNodeSchedule) HandleJobStateChange(key string, state uint8) {
 	if state == model.Runnable {
-		job, err := s.dao.GetJobInfo(key)
+		jd, jobId := s.jobDao(key)
+		job, err := jd.GetJobInfo(jobId)
 		if err != nil {
-			logger.Error(err)
+			s.jobLogger(key).Error(err)
 			return
 		}
 		err = s.handleAddJob(&job)
 		if err != nil {
-			logger.Error(err)
+			s.jobLogger(key).Error(err)
 			return
 		}
 	} else {
 		err := s.cancelJob(key)
 		if err != nil {
-			logger.Error(err)
+			s.jobLogger(key).Error(err)
 			return
 		}
 	}
@@ -206,6 +210,14 @@ func (s *MultiNodeSchedule) CreateTask(key string, execType uint8) func() {
 	}
 }
 
+// Owns false until the node list is synced.
+func (s *MultiNodeSchedule) Owns(key string) bool {
+	if m, ok := s.consistentMap.Load().(*consistenthash.Map); ok {
+		return m.Get(key) == s.NodeId
+	}
+	return false
+}
+
 func (s *MultiNodeSchedule) executable(key string) bool {
 	mp := s.consistentMap.Load()
 	m := mp.(*consistenthash.Map)
@@ -213,6 +225,7 @@ func (s *MultiNodeSchedule) executable(key string) bool {
 }
 func (s *MultiNodeSchedule) Remove(key string) {
 	_ = s.cancelJob(key)
+	s.InvalidateScript(key)
 }
 
 // cancelJob remove the job from the time wheel.
@@ -231,36 +244,51 @@ func (s *MultiNodeSchedule) startSub(ctx context.Context) {
 	pubSub := s.client.Subscribe(ctx, redisChannel)
 	subChanel := pubSub.Channel()
 	go func() {
-		select {
-		case <-ctx.Done():
-			{
-				_ = pubSub.Close()
-				return
-			}
-		case msg := <-subChanel: // get startSub msg.
-			{
-				s.handleSubEvent(msg)
+		for {
+			select {
+			case <-ctx.Done():
+				{
+					_ = pubSub.Close()
+					return
+				}
+			case msg := <-subChanel: // get startSub msg.
+				{
+					s.handleSubEvent(msg)
+				}
 			}
 		}
 	}()
 }
 
 func (s *MultiNodeSchedule) handleSubEvent(msg *redis.Message) {
+	if res := strings.Split(msg.Payload, ":"); len(res) > 2 {
+		if sentAt, err := strconv.ParseInt(res[len(res)-1], 10, 64); err == nil {
+			metrics.PubSubLag.Observe(time.Since(time.Unix(0, sentAt)).Seconds())
+		}
+	}
 	// job add.
 	if strings.Contains(msg.Payload, "jobAdd") {
 		res := strings.Split(msg.Payload, ":")
 		id := res[1]
-		entity, err := s.dao.GetJobInfo(id)
+		jd, jobId := s.jobDao(id)
+		entity, err := jd.GetJobInfo(jobId)
 		if err != nil {
-			logger.Error(fmt.Sprintf("could not load the job from db.id:%s", id))
+			s.jobLogger(id).Error("could not load the job from db.", err)
 		}
 		err = s.addJob(&entity)
 		if err != nil {
-			logger.Error(fmt.Sprintf("handle sub jobAdd event error:%s", err.Error()))
+			s.jobLogger(id).Error("handle sub jobAdd event error:", err)
 		}
 		return
 	}
 
+	// run cancel, only the node executing the run has it.
+	if strings.Contains(msg.Payload, "runCancel") {
+		res := strings.Split(msg.Payload, ":")
+		_ = s.tracker.localTracker.cancel(res[1])
+		return
+	}
+
 	// job cancel.
 	if strings.Contains(msg.Payload, "jobCancel") {
 		res := strings.Split(msg.Payload, ":")
@@ -271,18 +299,19 @@ func (s *MultiNodeSchedule) handleSubEvent(msg *redis.Message) {
 }
 
 func (s *MultiNodeSchedule) HandleJobTimeChange(key string) {
-	jb, err := s.dao.GetJobInfo(key)
+	jd, jobId := s.jobDao(key)
+	jb, err := jd.GetJobInfo(jobId)
 	if err == nil {
 		err = s.addJob(&jb)
 		if err != nil {
-			logger.Error(err)
+			s.jobLogger(key).Error(err)
 		}
 	} else {
-		logger.Error(err)
+		s.jobLogger(key).Error(err)
 	}
 	// notify other nodes.
 	err = s.notifyOtherNodes(fmt.Sprintf(fmt.Sprintf(jobAdd, key)))
 	if err != nil {
-		logger.Error(fmt.Sprintf("notify other nodes error: %s", err.Error()))
+		s.jobLogger(key).Error("notify other nodes error:", err)
 	}
 }
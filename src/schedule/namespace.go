@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"errors"
+	"strings"
+	"traitor/dao"
+	"traitor/dao/model"
+)
+
+var ErrQuotaExceeded = errors.New("namespace quota exceeded")
+
+// JobKey the key of the job in the time wheel, the program cache and the messages between the nodes.
+// the jobs of the default namespace keep their bare ids.
+func JobKey(ns string, jobId string) string {
+	if ns == "" || ns == model.DefaultNamespace {
+		return jobId
+	}
+	return ns + "/" + jobId
+}
+
+// SplitKey the namespace and the job id of the key.
+func SplitKey(key string) (string, string) {
+	if ns, jobId, ok := strings.Cut(key, "/"); ok {
+		return ns, jobId
+	}
+	return model.DefaultNamespace, key
+}
+
+// jobDao the dao of the job's namespace and the bare job id.
+func (s *schedule) jobDao(key string) (dao.Dao, string) {
+	ns, jobId := SplitKey(key)
+	return s.dao.Namespace(ns), jobId
+}
+
+// runnableJobs the runnable jobs of all the namespaces.
+func (s *schedule) runnableJobs() ([]model.JobEntity, error) {
+	nss, err := s.dao.GetNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.JobEntity, 0)
+	for _, n := range nss {
+		jbs, err := s.dao.Namespace(n.Name).GetRunnableJobs()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, jbs...)
+	}
+	return res, nil
+}
+
+// checkQuota whether another run of the namespace could start, it's called before the run is tracked.
+func (s *schedule) checkQuota(ns string) error {
+	n, err := s.dao.GetNamespace(ns)
+	if err != nil || n.MaxConcurrentRuns <= 0 {
+		return nil
+	}
+	runs, err := s.active.list()
+	if err != nil {
+		return nil // the quota is not a reason to skip the run.
+	}
+	count := 0
+	for _, run := range runs {
+		if run.Namespace == n.Name {
+			count++
+		}
+	}
+	if count >= n.MaxConcurrentRuns {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package schedule
+
+import (
+	"testing"
+	"traitor/dao/model"
+)
+
+func TestJobKey(t *testing.T) {
+	tests := []struct {
+		ns    string
+		jobId string
+		key   string
+	}{
+		{model.DefaultNamespace, "a", "a"},
+		{"team-a", "a", "team-a/a"},
+	}
+	for _, tt := range tests {
+		key := JobKey(tt.ns, tt.jobId)
+		if key != tt.key {
+			t.Errorf("JobKey() = %v, want %v", key, tt.key)
+		}
+		ns, jobId := SplitKey(key)
+		if ns != tt.ns || jobId != tt.jobId {
+			t.Errorf("SplitKey() = %v %v, want %v %v", ns, jobId, tt.ns, tt.jobId)
+		}
+	}
+	if JobKey("", "a") != "a" {
+		t.Errorf("JobKey() of no namespace should be the bare id")
+	}
+}
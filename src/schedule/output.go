@@ -0,0 +1,38 @@
+package schedule
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"traitor/js_module/debug_out"
+)
+
+// runOutput the console of a script run, kept in the run record.
+// every line is prefixed with the time, level and run id.
+type runOutput struct {
+	mu    sync.Mutex
+	runId string
+	buf   limitedBuffer
+	sink  outputSink
+}
+
+func (o *runOutput) Write(p []byte) (int, error) {
+	return o.WriteLevel(debug_out.LevelLog, p)
+}
+
+func (o *runOutput) WriteLevel(level string, p []byte) (int, error) {
+	line := fmt.Sprintf("%s [%s] [%s] %s", time.Now().Format(time.RFC3339Nano), level, o.runId, p)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, _ = o.buf.Write([]byte(line + "\n"))
+	if o.sink != nil {
+		o.sink.publish(o.runId, line)
+	}
+	return len(p), nil
+}
+
+func (o *runOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.buf.String()
+}
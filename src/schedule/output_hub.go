@@ -0,0 +1,208 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-redis/redis/v8"
+	"sync"
+	"time"
+	"traitor/logger"
+)
+
+// outputSink where the console lines of running scripts go, so they can be tailed.
+type outputSink interface {
+	publish(runId string, line string)
+	// finish close the subscriptions of the run.
+	finish(runId string)
+	subscribe(runId string) (<-chan string, func())
+}
+
+// tailBuffer lines are dropped for a subscriber that can't keep up.
+// it's also how many of the last lines of a run a new subscriber gets first.
+const tailBuffer = 256
+
+// localHub relay the output to the subscribers in this process.
+type localHub struct {
+	mu    sync.Mutex
+	subs  map[string]map[chan string]struct{}
+	lines map[string][]string // the last lines of the running scripts.
+}
+
+func makeLocalHub() *localHub {
+	return &localHub{subs: make(map[string]map[chan string]struct{}), lines: make(map[string][]string)}
+}
+
+func (h *localHub) publish(runId string, line string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	lines := append(h.lines[runId], line)
+	if len(lines) > tailBuffer {
+		lines = lines[len(lines)-tailBuffer:]
+	}
+	h.lines[runId] = lines
+	for ch := range h.subs[runId] {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+func (h *localHub) finish(runId string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[runId] {
+		close(ch)
+	}
+	delete(h.subs, runId)
+	delete(h.lines, runId)
+}
+
+func (h *localHub) subscribe(runId string) (<-chan string, func()) {
+	ch := make(chan string, tailBuffer)
+	h.mu.Lock()
+	for _, line := range h.lines[runId] {
+		ch <- line
+	}
+	if h.subs[runId] == nil {
+		h.subs[runId] = make(map[chan string]struct{})
+	}
+	h.subs[runId][ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[runId][ch]; ok {
+			delete(h.subs[runId], ch)
+			close(ch)
+			if len(h.subs[runId]) == 0 {
+				delete(h.subs, runId)
+			}
+		}
+	}
+}
+
+const (
+	outputChannel = "traitor_run_output:"
+	outputLines   = "traitor_run_lines:" // the list of the last lines of a run.
+	outputTTL     = 24 * time.Hour       // the lines of a run whose node died expire.
+	outputQueue   = 1024
+)
+
+type outputMessage struct {
+	Seq  int64  `json:"seq,omitempty"` // the number of the line in the run.
+	Line string `json:"line,omitempty"`
+	End  bool   `json:"end,omitempty"`
+}
+
+type queuedOutput struct {
+	runId string
+	msg   outputMessage
+}
+
+// redisHub relay the output through redis, so any node can tail a run executed by another node.
+// the lines are sent by a goroutine, so a script never waits for redis to print.
+type redisHub struct {
+	client *redis.Client
+	queue  chan queuedOutput
+}
+
+func makeRedisHub(client *redis.Client) *redisHub {
+	h := &redisHub{client: client, queue: make(chan queuedOutput, outputQueue)}
+	go h.send()
+	return h
+}
+
+// send the queued messages in order, the last lines of a run are kept besides publishing them.
+func (h *redisHub) send() {
+	seqs := make(map[string]int64)
+	for q := range h.queue {
+		ctx := context.TODO()
+		var err error
+		if q.msg.End {
+			delete(seqs, q.runId)
+			buffer, _ := json.Marshal(q.msg)
+			_, err = h.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+				p.Publish(ctx, outputChannel+q.runId, buffer)
+				p.Del(ctx, outputLines+q.runId)
+				return nil
+			})
+		} else {
+			seqs[q.runId]++
+			q.msg.Seq = seqs[q.runId]
+			buffer, _ := json.Marshal(q.msg)
+			key := outputLines + q.runId
+			_, err = h.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+				p.RPush(ctx, key, buffer)
+				p.LTrim(ctx, key, -tailBuffer, -1)
+				p.Expire(ctx, key, outputTTL)
+				p.Publish(ctx, outputChannel+q.runId, buffer)
+				return nil
+			})
+		}
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+}
+
+// publish the line is dropped if redis can't keep up, like for a slow subscriber.
+func (h *redisHub) publish(runId string, line string) {
+	select {
+	case h.queue <- queuedOutput{runId: runId, msg: outputMessage{Line: line}}:
+	default:
+	}
+}
+
+func (h *redisHub) finish(runId string) {
+	h.queue <- queuedOutput{runId: runId, msg: outputMessage{End: true}}
+}
+
+func (h *redisHub) subscribe(runId string) (<-chan string, func()) {
+	ch := make(chan string, tailBuffer)
+	ctx := context.TODO()
+	pubSub := h.client.Subscribe(ctx, outputChannel+runId)
+	// wait for the subscription, no line published after it returns is missed.
+	if _, err := pubSub.Receive(ctx); err != nil {
+		logger.Error(err)
+		_ = pubSub.Close()
+		close(ch)
+		return ch, func() {}
+	}
+	// then the lines printed before, the ones also received from the channel are skipped by their seq.
+	var last int64
+	lines, err := h.client.LRange(ctx, outputLines+runId, 0, -1).Result()
+	if err != nil {
+		logger.Error(err)
+	}
+	for _, v := range lines {
+		var m outputMessage
+		if json.Unmarshal([]byte(v), &m) == nil {
+			ch <- m.Line
+			last = m.Seq
+		}
+	}
+	go func() {
+		defer close(ch)
+		for msg := range pubSub.Channel() {
+			var m outputMessage
+			if json.Unmarshal([]byte(msg.Payload), &m) != nil {
+				continue
+			}
+			if m.End {
+				_ = pubSub.Close()
+				return
+			}
+			if m.Seq <= last {
+				continue
+			}
+			select {
+			case ch <- m.Line:
+			default:
+			}
+		}
+	}()
+	return ch, func() {
+		_ = pubSub.Close()
+	}
+}
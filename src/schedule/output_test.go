@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"traitor/js_module/debug_out"
+)
+
+func Test_runOutput(t *testing.T) {
+	exec := newExecutor()
+	out := &runOutput{runId: "run-1"}
+	debug_out.SetIoWriter(exec.Vm, out)
+	_, err := exec.Vm.RunString(`console.log("hello %s", "world"); console.warn("careful"); console.info("fyi"); console.debug("details")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("want 4 lines, got %q", out.String())
+	}
+	if strings.HasSuffix(lines[0], " [log] [run-1] hello world") == false {
+		t.Errorf("unexpected line %q", lines[0])
+	}
+	if strings.HasSuffix(lines[1], " [warn] [run-1] careful") == false {
+		t.Errorf("unexpected line %q", lines[1])
+	}
+	if strings.HasSuffix(lines[2], " [info] [run-1] fyi") == false || strings.HasSuffix(lines[3], " [debug] [run-1] details") == false {
+		t.Errorf("unexpected lines %q", lines[2:])
+	}
+}
+
+func Test_localHub(t *testing.T) {
+	h := makeLocalHub()
+	lines, cancel := h.subscribe("run-1")
+	defer cancel()
+	out := &runOutput{runId: "run-1", sink: h}
+	_, _ = out.WriteLevel(debug_out.LevelError, []byte("failed"))
+	h.publish("run-2", "other run")
+	h.finish("run-1")
+
+	var got []string
+	for line := range lines {
+		got = append(got, line)
+	}
+	if len(got) != 1 || strings.HasSuffix(got[0], " [error] [run-1] failed") == false {
+		t.Errorf("unexpected lines %q", got)
+	}
+}
+
+func Test_localHub_backlog(t *testing.T) {
+	h := makeLocalHub()
+	h.publish("run-1", "before")
+	lines, cancel := h.subscribe("run-1")
+	defer cancel()
+	h.publish("run-1", "after")
+	h.finish("run-1")
+
+	var got []string
+	for line := range lines {
+		got = append(got, line)
+	}
+	if strings.Join(got, ",") != "before,after" {
+		t.Errorf("unexpected lines %q", got)
+	}
+	if len(h.lines) != 0 {
+		t.Errorf("the lines of a finished run are kept: %v", h.lines)
+	}
+}
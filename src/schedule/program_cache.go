@@ -0,0 +1,106 @@
+package schedule
+
+import (
+	executor "github.com/KaniuBillows/traitor-plugin"
+	"github.com/dop251/goja"
+	"sync"
+	"traitor/js_module"
+)
+
+type cachedProgram struct {
+	revision int64
+	program  *goja.Program
+}
+
+// programCache keeps the compiled script of each job, keyed by job id and script revision.
+type programCache struct {
+	mu       sync.RWMutex
+	programs map[string]cachedProgram
+}
+
+func makeProgramCache() *programCache {
+	return &programCache{programs: make(map[string]cachedProgram)}
+}
+
+func (c *programCache) get(key string, revision int64) (*goja.Program, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	p, ok := c.programs[key]
+	if ok == false || p.revision != revision {
+		return nil, false
+	}
+	return p.program, true
+}
+
+func (c *programCache) put(key string, revision int64, program *goja.Program) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.programs[key] = cachedProgram{revision: revision, program: program}
+}
+
+func (c *programCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.programs, key)
+}
+
+const vmPoolSize = 8
+
+// warmExecutor a runtime and the generation of the modules it was loaded with.
+type warmExecutor struct {
+	exec       *executor.Executor
+	generation uint64
+}
+
+// vmPool pre-initialize runtimes with console and modules loaded.
+// a runtime is used only once, so scripts never share global state.
+type vmPool struct {
+	executors chan warmExecutor
+	stopChan  chan struct{}
+}
+
+func makeVmPool(size int) *vmPool {
+	p := &vmPool{
+		executors: make(chan warmExecutor, size),
+		stopChan:  make(chan struct{}),
+	}
+	go p.fill()
+	return p
+}
+
+func newExecutor() *executor.Executor {
+	exec := executor.MakeExecutor()
+	js_module.LoadModules(exec) // native modules support.
+	return exec
+}
+
+func (p *vmPool) fill() {
+	for {
+		exec := executor.MakeExecutor()
+		gen := js_module.LoadModules(exec)
+		select {
+		case p.executors <- warmExecutor{exec: exec, generation: gen}:
+		case <-p.stopChan:
+			return
+		}
+	}
+}
+
+// get a warm runtime, or create one if the pool is drained.
+// the runtimes loaded before a module was registered or replaced are dropped.
+func (p *vmPool) get() *executor.Executor {
+	for {
+		select {
+		case w := <-p.executors:
+			if w.generation == js_module.ModulesGeneration() {
+				return w.exec
+			}
+		default:
+			return newExecutor()
+		}
+	}
+}
+
+func (p *vmPool) stop() {
+	close(p.stopChan)
+}
@@ -0,0 +1,88 @@
+package schedule
+
+import (
+	"context"
+	"github.com/dop251/goja"
+	"testing"
+	"time"
+	"traitor/dao/model"
+	"traitor/js_module"
+)
+
+const benchScript = `
+var sum = 0
+for (var i = 0; i < 100; i++) {
+	sum += i
+}
+JSON.stringify({sum: sum})
+`
+
+func Test_programCache(t *testing.T) {
+	c := makeProgramCache()
+	prg := goja.MustCompile("job", benchScript, false)
+	c.put("job", 1, prg)
+	if p, ok := c.get("job", 1); ok == false || p != prg {
+		t.Error("program should be cached")
+	}
+	if _, ok := c.get("job", 2); ok {
+		t.Error("a new revision should miss the cache")
+	}
+	c.remove("job")
+	if _, ok := c.get("job", 1); ok {
+		t.Error("removed program should miss the cache")
+	}
+}
+
+type poolTestModule struct{}
+
+func (poolTestModule) GetName() string { return "pool_test" }
+
+func (poolTestModule) ModuleLoader(runtime *goja.Runtime, module *goja.Object) {
+	_ = module.Get("exports").(*goja.Object).Set("ok", true)
+}
+
+func Test_vmPool(t *testing.T) {
+	pool := makeVmPool(1)
+	defer pool.stop()
+	for len(pool.executors) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// the warm runtime was loaded before the module.
+	js_module.RegistryPlugin(poolTestModule{})
+	exec := pool.get()
+	if v, err := exec.Vm.RunString(`require("pool_test").ok`); err != nil || v.ToBoolean() == false {
+		t.Errorf("the runtime misses the module registered after the pool was filled: %v", err)
+	}
+}
+
+// BenchmarkColdRun the setup of every fire without cache and pool.
+func BenchmarkColdRun(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		exec := newExecutor()
+		_, err := exec.Vm.RunString(benchScript)
+		if err != nil {
+			b.Fatal(err)
+		}
+		exec.Wait.Wait()
+	}
+}
+
+// BenchmarkWarmRun a cached program on a pre-warmed runtime, through the path of a fire.
+// the pool is refilled between fires, only the latency of a fire is measured.
+func BenchmarkWarmRun(b *testing.B) {
+	s := makeStandalone(nil)
+	defer s.vmPool.stop()
+	j := &model.JobEntity{JobId: "job", Revision: 1}
+	s.programs.put(JobKey(j.Namespace, j.JobId), j.Revision, goja.MustCompile("job", benchScript, false))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		for len(s.vmPool.executors) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		b.StartTimer()
+		if err := s.runScript(context.Background(), j, &model.RunEntity{RunId: "run"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,3 +2,4 @@ package schedule
 
 const jobAdd = "jobAdd:%s"
 const jobCancel = "jobCancel:%s"
+const runCancel = "runCancel:%s"
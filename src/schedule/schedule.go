@@ -3,17 +3,25 @@ package schedule
 import (
 	"context"
 	"errors"
-	"fmt"
 	executor "github.com/KaniuBillows/traitor-plugin"
+	"github.com/dop251/goja"
+	"github.com/google/uuid"
 	"github.com/gorhill/cronexpr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"io"
 	"sync"
 	"time"
+	"traitor/alert"
 	"traitor/dao"
 	"traitor/dao/model"
 	"traitor/js_module"
 	"traitor/js_module/debug_out"
+	"traitor/js_module/debugger"
+	"traitor/js_module/runctx"
 	"traitor/logger"
+	"traitor/metrics"
+	"traitor/tracing"
 )
 
 // Schedule just exec the job and notify other nodes.
@@ -27,35 +35,97 @@ type Schedule interface {
 	// handle cron or delay change.
 	HandleJobTimeChange(key string)
 	CreateTask(key string, execType uint8) func()
-	CreateTaskForDebug(key string, writer io.Writer) (func(), *sync.WaitGroup)
+	// Trigger run the job once now on this node, the schedule of the job is not changed.
+	// the id of the run is returned, its record is saved when the run starts.
+	Trigger(key string) string
+	// CreateTaskForDebug the script is instrumented for dbg if it's not nil.
+	// the draft is run instead of the saved script if it's not nil.
+	CreateTaskForDebug(key string, draft *Draft, writer io.Writer, dbg *debugger.Debugger) (func(), *sync.WaitGroup)
 	ResolveCron(str string) (time.Duration, error)
 	Remove(key string)
+	// InvalidateScript drop the compiled script after it was changed.
+	InvalidateScript(key string)
+	// TailRun subscribe the console lines of a running script, the channel is closed when the run finished.
+	// the last lines printed before the subscription come first.
+	// call the returned func to unsubscribe.
+	TailRun(runId string) (<-chan string, func())
+	// ActiveRuns the executions in flight, oldest first.
+	ActiveRuns() ([]model.RunEntity, error)
+	// CancelRun stop an execution in flight, ErrRunNotActive if it's unknown or finished.
+	CancelRun(runId string) error
+	// Ready the readiness checks of the node, a nil error means the check passed.
+	Ready() map[string]error
+	// Cluster the live nodes and the jobs they own.
+	Cluster() (model.ClusterInfo, error)
+	// LinkRun the next run of the job on this node links to the span of ctx, e.g. the API call that enabled it.
+	LinkRun(key string, ctx context.Context)
+	// Owns whether this node is the one to do the work of the key, like running the job of it.
+	Owns(key string) bool
 }
+
+// Draft an unsaved script, Params is the global `params` of the script.
+type Draft struct {
+	Script string
+	Params map[string]any
+}
+
 type schedule struct {
 	dao       dao.Dao
 	timeWheel *timeWheel
+	nodeId    string
+	programs  *programCache
+	vmPool    *vmPool
+	output    outputSink
+	active    runTracker
+	links     *sync.Map // the trace.Link of the next run, by key.
+}
+
+func makeSchedule(d dao.Dao, nodeId string) schedule {
+	s := schedule{
+		timeWheel: makeTimeWheel(),
+		dao:       d,
+		nodeId:    nodeId,
+		programs:  makeProgramCache(),
+		vmPool:    makeVmPool(vmPoolSize),
+		output:    makeLocalHub(),
+		active:    makeLocalTracker(),
+		links:     &sync.Map{},
+	}
+	return s
+}
+
+func (s *schedule) InvalidateScript(key string) {
+	s.programs.remove(key)
+}
+
+func (s *schedule) TailRun(runId string) (<-chan string, func()) {
+	return s.output.subscribe(runId)
+}
+
+func (s *schedule) ActiveRuns() ([]model.RunEntity, error) {
+	return s.active.list()
+}
+
+func (s *schedule) CancelRun(runId string) error {
+	return s.active.cancel(runId)
+}
+
+func (s *schedule) LinkRun(key string, ctx context.Context) {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		s.links.Store(key, trace.Link{SpanContext: sc})
+	}
+}
+
+func (s *schedule) Trigger(key string) string {
+	runId := uuid.NewString()
+	go s.runTask(key, runId)
+	return runId
 }
 
 func (s *schedule) CreateTask(key string, execType uint8) func() {
 
 	execFunc := func() {
-		exec := executor.MakeExecutor()
-		js_module.LoadModules(exec) // native modules support.
-		var sc, err = s.dao.GetJobScript(key)
-		if err != nil {
-			logger.Error(fmt.Sprintf("running Task failed:%s download script error.", key))
-			return
-		}
-		_, err = exec.Vm.RunString(sc.Script) // running logic.
-		if err != nil {
-			logger.Error(err)
-		}
-		// update last exec time
-		err = s.dao.UpdateJob(key, map[string]any{model.LastExecTime: time.Now()})
-		if err != nil {
-			logger.Error(err)
-		}
-		exec.Wait.Wait()
+		s.runTask(key, uuid.NewString())
 	}
 	if execType == model.DelayExecute { // only once for delay.
 		return execFunc
@@ -63,37 +133,221 @@ func (s *schedule) CreateTask(key string, execType uint8) func() {
 		return func() {
 			execFunc()
 			// after execute re-add into for next time.
-			j, err := s.dao.GetJobInfo(key)
+			jd, jobId := s.jobDao(key)
+			j, err := jd.GetJobInfo(jobId)
 			if err != nil {
-				logger.Error(fmt.Sprintf("re-add timing job error, cannot get the job entity:%s", key))
+				s.jobLogger(key).Error("re-add timing job error, cannot get the job entity.", err)
 				return
 			}
 			err = s.addJob(&j)
 			if err != nil {
-				logger.Error(fmt.Sprintf("re-add timing job error:  %s", err.Error()))
+				s.jobLogger(key).Error("re-add timing job error:", err)
 			}
 		}
 	}
 }
+
+// runTask run the job once as the run runId.
+func (s *schedule) runTask(key string, runId string) {
+	// the root span of the run, linked to the API call that asked for it if any.
+	opts := []trace.SpanStartOption{trace.WithAttributes(tracing.JobId.String(key), tracing.NodeId.String(s.nodeId))}
+	if l, ok := s.links.LoadAndDelete(key); ok {
+		opts = append(opts, trace.WithLinks(l.(trace.Link)))
+	}
+	ctx, span := tracing.Tracer().Start(context.Background(), "job.fire", opts...)
+	jd, jobId := s.jobDao(key)
+	j, err := jd.GetJobInfo(jobId)
+	if err != nil {
+		s.jobLogger(key).Error("running Task failed: cannot get the job entity.", err)
+		tracing.End(span, err)
+		return
+	}
+	metrics.RunningJobs.Inc()
+	defer metrics.RunningJobs.Dec()
+	run := s.beginRun(jd, &j, runId)
+	span.SetAttributes(tracing.RunId.String(run.RunId))
+	log := s.runLogger(&run)
+	log.Info("run started")
+	ctx, cancel := context.WithCancel(ctx)
+	quotaErr := s.checkQuota(j.Namespace) // before the run is counted.
+	s.active.add(run, cancel)
+	alerts := alert.New(jd)
+	stopTimeout := alerts.RunStarted(&j, &run)
+	switch {
+	case quotaErr != nil:
+		err = quotaErr
+	case j.TaskType == model.CommandTask:
+		err = s.runCommand(ctx, j.Command, &run)
+	case j.TaskType == model.HttpTask:
+		err = s.runHttp(ctx, j.Http, &run)
+	default:
+		err = s.runScript(ctx, &j, &run)
+	}
+	cancelled := ctx.Err() != nil // only CancelRun could cancel it before the run finished.
+	cancel()
+	if err != nil {
+		log.Error("running Task failed:", err)
+	}
+	s.finishRun(jd, &run, err, cancelled)
+	s.active.remove(run.RunId) // after the record is saved, a run not active is finished or lost.
+	stopTimeout()
+	alerts.RunFinished(&j, &run)
+	tracing.End(span, err)
+	metrics.RunsTotal.WithLabelValues(j.Namespace, taskTypes[j.TaskType], runStates[run.State]).Inc()
+	metrics.RunDuration.WithLabelValues(j.Namespace, taskTypes[j.TaskType]).Observe(run.EndAt.Sub(*run.StartAt).Seconds())
+	log.With("state", run.State, "duration", run.EndAt.Sub(*run.StartAt)).Info("run finished")
+	s.output.finish(run.RunId) // after the record is saved, a new stream gets the full output from it.
+	// update last exec time
+	err = jd.UpdateJob(jobId, map[string]any{model.LastExecTime: time.Now()})
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// runScript execute the job's javascript and wait for the pending async calls.
+// the vm is interrupted and its pending calls are aborted when ctx is done.
+func (s *schedule) runScript(ctx context.Context, j *model.JobEntity, run *model.RunEntity) (err error) {
+	prg, err := s.loadProgram(ctx, j)
+	if err != nil {
+		return err
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "script.execute")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	exec := s.vmPool.get()
+	runctx.Set(exec.Vm, ctx)
+	defer runctx.Remove(exec.Vm)
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			exec.Vm.Interrupt("run cancelled")
+		case <-finished:
+		}
+	}()
+	out := &runOutput{runId: run.RunId, sink: s.output}
+	debug_out.SetIoWriter(exec.Vm, out) // the output of this run is kept in its record.
+	_ = exec.Vm.Set("params", paramsOf(j.Params))
+	_, err = exec.Vm.RunProgram(prg) // running logic.
+	exec.Wait.Wait()
+	run.Output = out.String()
+	return err
+}
+
+// loadProgram get the compiled script from cache, or download and compile it.
+func (s *schedule) loadProgram(ctx context.Context, j *model.JobEntity) (prg *goja.Program, err error) {
+	_, span := tracing.Tracer().Start(ctx, "script.fetch")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	key := JobKey(j.Namespace, j.JobId)
+	if prg, ok := s.programs.get(key, j.Revision); ok {
+		span.SetAttributes(attribute.Bool("traitor.cache.hit", true))
+		return prg, nil
+	}
+	span.SetAttributes(attribute.Bool("traitor.cache.hit", false))
+	sc, err := s.dao.Namespace(j.Namespace).GetJobScript(j.JobId)
+	if err != nil {
+		return nil, errors.New("download script error")
+	}
+	prg, err = goja.Compile(j.JobId, sc.Script, false)
+	if err != nil {
+		return nil, err
+	}
+	s.programs.put(key, j.Revision, prg)
+	return prg, nil
+}
+
+// beginRun save a running record for the job in its namespace.
+func (s *schedule) beginRun(jd dao.Dao, j *model.JobEntity, runId string) model.RunEntity {
+	now := time.Now()
+	run := model.RunEntity{
+		RunId:     runId,
+		JobId:     j.JobId,
+		Namespace: j.Namespace,
+		NodeId:    s.nodeId,
+		StartAt:   &now,
+		State:     model.RunRunning,
+	}
+	_, err := jd.AddRun(run)
+	if err != nil {
+		s.runLogger(&run).Error("save run record error:", err)
+	}
+	return run
+}
+
+// finishRun save the result of the run.
+func (s *schedule) finishRun(jd dao.Dao, run *model.RunEntity, runErr error, cancelled bool) {
+	now := time.Now()
+	run.EndAt = &now
+	run.State = model.RunSuccess
+	if cancelled {
+		run.State = model.RunCancelled
+		run.Error = "run cancelled"
+	} else if runErr != nil {
+		run.State = model.RunFailed
+		run.Error = runErr.Error()
+	}
+	err := jd.UpdateRun(run.RunId, map[string]any{
+		model.EndAt:      now,
+		model.RunState:   run.State,
+		model.RunError:   run.Error,
+		model.ExitCode:   run.ExitCode,
+		model.Stdout:     run.Stdout,
+		model.Stderr:     run.Stderr,
+		model.StatusCode: run.StatusCode,
+		model.Response:   run.Response,
+		model.Output:     run.Output,
+	})
+	if err != nil {
+		s.runLogger(run).Error("save run record error:", err)
+	}
+}
+
+// runStates the outcome label of the metrics.
+var runStates = map[uint8]string{
+	model.RunSuccess:   "success",
+	model.RunFailed:    "failed",
+	model.RunCancelled: "cancelled",
+}
+
+// taskTypes the task label of the metrics, the jobs are not labelled as every ad-hoc run is a new job.
+var taskTypes = map[uint8]string{
+	model.ScriptTask:  "script",
+	model.CommandTask: "command",
+	model.HttpTask:    "http",
+}
+
+// jobLogger attach the job and node to the entries.
+func (s *schedule) jobLogger(key string) *logger.Entry {
+	return logger.With(logger.JobIdField, key, "nodeId", s.nodeId)
+}
+
+func (s *schedule) runLogger(run *model.RunEntity) *logger.Entry {
+	return s.jobLogger(JobKey(run.Namespace, run.JobId)).With("runId", run.RunId)
+}
 func (s *schedule) addJob(j *model.JobEntity) error {
-	fn := s.CreateTask(j.JobId, j.ExecType)
+	key := JobKey(j.Namespace, j.JobId)
+	fn := s.CreateTask(key, j.ExecType)
 	if j.ExecType == model.TimingExecute {
 		d, err := s.ResolveCron(j.Cron)
 		if err != nil {
 			return err
 		}
-		s.timeWheel.AddJob(d, j.JobId, fn)
+		s.timeWheel.AddJob(d, key, fn)
 	} else {
 		delay := j.ExecAt.ToTime().Sub(time.Now())
 		if delay <= 0 {
 			return errors.New("delay job has expired")
 		}
-		s.timeWheel.AddJob(delay, j.JobId, fn)
+		s.timeWheel.AddJob(delay, key, fn)
 	}
 	return nil
 }
 
-func (s *schedule) CreateTaskForDebug(key string, writer io.Writer) (func(), *sync.WaitGroup) {
+func (s *schedule) CreateTaskForDebug(key string, draft *Draft, writer io.Writer, dbg *debugger.Debugger) (func(), *sync.WaitGroup) {
 	wt := sync.WaitGroup{}
 	wt.Add(1)
 	return func() {
@@ -104,12 +358,30 @@ func (s *schedule) CreateTaskForDebug(key string, writer io.Writer) (func(), *sy
 		exec := executor.MakeExecutor()
 		js_module.LoadModulesForDebugMode(exec)
 		debug_out.SetIoWriter(exec.Vm, writer) // this vm would use this writer.
-		var sc, err = s.dao.GetJobScript(key)
+		src, err := s.debugScript(key, draft)
 		if err != nil {
-			logger.Error(fmt.Sprintf("running Task failed:%s download script error.", key))
+			s.jobLogger(key).Error("running Task failed: download script error.", err)
 			return
 		}
-		_, err = exec.Vm.RunString(sc.Script) // running logic.
+		var params map[string]any
+		if draft != nil && draft.Params != nil {
+			params = draft.Params
+		} else {
+			jd, jobId := s.jobDao(key)
+			if j, err := jd.GetJobInfo(jobId); err == nil { // the draft of a new job has none.
+				params = j.Params
+			}
+		}
+		_ = exec.Vm.Set("params", paramsOf(params))
+		if dbg != nil {
+			src, err = debugger.Instrument(src)
+			if err != nil {
+				_, _ = writer.Write([]byte(err.Error()))
+				return
+			}
+			dbg.Attach(exec.Vm)
+		}
+		_, err = exec.Vm.RunString(src) // running logic.
 		if err != nil {
 			errInfo := err.Error()
 			buffer := []byte(errInfo)
@@ -122,6 +394,24 @@ func (s *schedule) CreateTaskForDebug(key string, writer io.Writer) (func(), *sy
 	}, &wt
 }
 
+// debugScript get the draft, or the saved script if there's no draft.
+func (s *schedule) debugScript(key string, draft *Draft) (string, error) {
+	if draft != nil && draft.Script != "" {
+		return draft.Script, nil
+	}
+	jd, jobId := s.jobDao(key)
+	sc, err := jd.GetJobScript(jobId)
+	return sc.Script, err
+}
+
+// paramsOf the params of a script, never nil so it could read params.x.
+func paramsOf(params map[string]any) map[string]any {
+	if params == nil {
+		return map[string]any{}
+	}
+	return params
+}
+
 // ResolveCron
 // return the delay time of the cron.
 func (s *schedule) ResolveCron(str string) (time.Duration, error) {
@@ -131,6 +421,28 @@ func (s *schedule) ResolveCron(str string) (time.Duration, error) {
 	}
 	return t.Sub(time.Now()), nil
 }
+
+// NextFire when the job would run next, nil if it's stopped or would never run again.
+func NextFire(j *model.JobEntity, now time.Time) *time.Time {
+	if j.State != model.Runnable {
+		return nil
+	}
+	var t time.Time
+	if j.ExecType == model.TimingExecute {
+		expr, err := cronexpr.Parse(j.Cron)
+		if err != nil {
+			return nil
+		}
+		t = expr.Next(now)
+	} else if j.ExecAt != nil {
+		t = j.ExecAt.ToTime()
+	}
+	if t.IsZero() || t.Before(now) {
+		return nil
+	}
+	return &t
+}
+
 func StartMultiNode(redisStr string, mongoUri string, cluster string) (Schedule, dao.Dao) {
 	d := dao.CreateMongoDao(mongoUri, cluster)
 	schedule := makeMultiNode(redisStr, d, cluster)
@@ -4,7 +4,6 @@ import (
 	"context"
 	"traitor/dao"
 	"traitor/dao/model"
-	"traitor/logger"
 )
 
 type StandaloneSchedule struct {
@@ -13,7 +12,7 @@ type StandaloneSchedule struct {
 
 func makeStandalone(d dao.Dao) *StandaloneSchedule {
 	s := &StandaloneSchedule{
-		schedule: schedule{timeWheel: makeTimeWheel(), dao: d},
+		schedule: makeSchedule(d, ""),
 	}
 	return s
 }
@@ -24,7 +23,7 @@ func (s *StandaloneSchedule) Start(_ context.Context) {
 
 // load jobs from db.
 func (s *StandaloneSchedule) initJobs() {
-	jbs, err := s.dao.GetRunnableJobs()
+	jbs, err := s.runnableJobs()
 	if err != nil {
 		panic(err)
 	}
@@ -32,32 +31,39 @@ func (s *StandaloneSchedule) initJobs() {
 		_ = s.addJob(&jb)
 	}
 }
+func (s *StandaloneSchedule) Owns(_ string) bool {
+	return true
+}
+
 func (s *StandaloneSchedule) Close() {
 	s.timeWheel.stop()
+	s.vmPool.stop()
 }
 
 func (s *StandaloneSchedule) HandleJobStateChange(key string, state uint8) {
 	if state == model.Runnable {
-		j, err := s.dao.GetJobInfo(key)
+		jd, jobId := s.jobDao(key)
+		j, err := jd.GetJobInfo(jobId)
 		if err != nil {
-			logger.Error(err)
+			s.jobLogger(key).Error(err)
 			return
 		}
 		err = s.addJob(&j)
 		if err != nil {
-			logger.Error(err)
+			s.jobLogger(key).Error(err)
 			return
 		}
 	} else {
 		err := s.cancelJob(key)
 		if err != nil {
-			logger.Error(err)
+			s.jobLogger(key).Error(err)
 			return
 		}
 	}
 }
 func (s *StandaloneSchedule) Remove(key string) {
 	_ = s.cancelJob(key)
+	s.InvalidateScript(key)
 }
 
 func (s *StandaloneSchedule) cancelJob(key string) error {
@@ -66,14 +72,15 @@ func (s *StandaloneSchedule) cancelJob(key string) error {
 }
 
 func (s *StandaloneSchedule) HandleJobTimeChange(key string) {
-	jb, err := s.dao.GetJobInfo(key)
+	jd, jobId := s.jobDao(key)
+	jb, err := jd.GetJobInfo(jobId)
 	if err != nil {
-		logger.Error(err)
+		s.jobLogger(key).Error(err)
 		return
 	}
 	err = s.addJob(&jb)
 	if err != nil {
-		logger.Error(err)
+		s.jobLogger(key).Error(err)
 	}
 
 }
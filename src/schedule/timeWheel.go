@@ -2,8 +2,11 @@ package schedule
 
 import (
 	"container/list"
+	"errors"
+	"sync/atomic"
 	"time"
 	"traitor/logger"
+	"traitor/metrics"
 )
 
 const (
@@ -26,7 +29,8 @@ type timeWheel struct {
 	addTaskChan    chan task
 	removeTaskChan chan string
 	stopChannel    chan bool
-	running        bool
+	running        atomic.Bool  // read by the readiness check on other goroutines.
+	lastTick       atomic.Int64 // unix nano of the last tick.
 }
 type task struct {
 	delay         time.Duration
@@ -46,7 +50,6 @@ func makeTimeWheel() *timeWheel {
 		addTaskChan:    make(chan task),
 		removeTaskChan: make(chan string),
 		stopChannel:    make(chan bool),
-		running:        false,
 	}
 	for i := 0; i < slotNums; i++ {
 		timeWheel.slots[i] = list.New()
@@ -55,15 +58,28 @@ func makeTimeWheel() *timeWheel {
 }
 
 func (t *timeWheel) start() {
-	if t.running != false {
+	if t.running.CompareAndSwap(false, true) == false {
 		return
 	}
 	t.ticker = time.NewTicker(t.interval)
+	t.lastTick.Store(time.Now().UnixNano())
 	go t.handleEvent()
-	t.running = true
+}
+
+// check the loop is ticking, a few intervals are tolerated as the loop may be busy.
+func (t *timeWheel) check() error {
+	if t.running.Load() == false {
+		return errors.New("time wheel is not started")
+	}
+	if time.Since(time.Unix(0, t.lastTick.Load())) > 3*t.interval {
+		return errors.New("time wheel is not ticking")
+	}
+	return nil
 }
 func (t *timeWheel) stop() {
-	t.stopChannel <- false
+	if t.running.CompareAndSwap(true, false) {
+		t.stopChannel <- true
+	}
 }
 func (t *timeWheel) removeJob(key string) {
 	t.removeTaskChan <- key
@@ -74,6 +90,7 @@ func (t *timeWheel) handleRemove(key string) {
 		ls := t.slots[loc.slotIndex]
 		ls.Remove(loc.elem)
 		delete(t.locationMap, key)
+		metrics.PendingTasks.Dec()
 	}
 }
 
@@ -96,7 +113,8 @@ func (t *timeWheel) handleAddTask(task task) {
 	pos, circle := t.getPositionAndCircle(task.delay)
 	task.circle = circle
 
-	e := t.slots[pos].PushBack(task)
+	e := t.slots[pos].PushBack(&task)
+	metrics.PendingTasks.Inc()
 	loc := &location{
 		slotIndex: pos,
 		elem:      e,
@@ -135,6 +153,7 @@ func (t *timeWheel) handleEvent() {
 	}
 }
 func (t *timeWheel) tickHandler() {
+	t.lastTick.Store(time.Now().UnixNano())
 	// find current slots
 	l := t.slots[t.currentPos]
 	// update currentPos to next and wait for next tick tok.
@@ -143,7 +162,7 @@ func (t *timeWheel) tickHandler() {
 	} else {
 		t.currentPos++
 	}
-	go t.scanAndRunTask(l) // new coroutine handle tasks in the slotIndex.
+	t.scanAndRunTask(l) // the jobs are executed async, scan on the loop so the slots are not shared.
 }
 
 func (t *timeWheel) scanAndRunTask(l *list.List) {
@@ -167,6 +186,7 @@ func (t *timeWheel) scanAndRunTask(l *list.List) {
 		next := elem.Next()
 		l.Remove(elem)
 		delete(t.locationMap, task.key)
+		metrics.PendingTasks.Dec()
 
 		elem = next
 	}
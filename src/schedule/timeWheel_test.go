@@ -0,0 +1,56 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"traitor/dao"
+)
+
+func Test_timeWheel_check(t *testing.T) {
+	tw := makeTimeWheel()
+	tw.interval = time.Hour // no tick during the test, lastTick is set by hand.
+	if err := tw.check(); err == nil {
+		t.Fatal("check() of a wheel not started passed")
+	}
+	tw.start()
+	if err := tw.check(); err != nil {
+		t.Fatalf("check() of a started wheel = %v", err)
+	}
+	tw.lastTick.Store(time.Now().Add(-4 * tw.interval).UnixNano())
+	if err := tw.check(); err == nil {
+		t.Fatal("check() of a wheel not ticking passed")
+	}
+	tw.stop()
+	tw.stop() // stopping again does not block.
+	if err := tw.check(); err == nil {
+		t.Fatal("check() of a stopped wheel passed")
+	}
+}
+
+// pingDao a dao answering Ping with err.
+type pingDao struct {
+	dao.Dao
+	err error
+}
+
+func (d pingDao) Ping() error {
+	return d.err
+}
+
+func TestStandaloneSchedule_Ready(t *testing.T) {
+	d := &pingDao{}
+	s := makeStandalone(d)
+	defer s.Close()
+	s.Start(context.Background())
+	for name, err := range s.Ready() {
+		if err != nil {
+			t.Errorf("check %s = %v", name, err)
+		}
+	}
+	d.err = errors.New("down")
+	if checks := s.Ready(); checks["dao"] != d.err || checks["timeWheel"] != nil {
+		t.Errorf("Ready() = %v, want only the dao failed", checks)
+	}
+}
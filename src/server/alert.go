@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+	"traitor/alert"
+	"traitor/dao"
+	"traitor/dao/model"
+)
+
+// redacted what the secrets of the channels are listed as, an update with it keeps the secret saved.
+const redacted = "******"
+
+// ChannelList the channels, without the smtp passwords, the values of the webhook headers and the urls of the chat webhooks.
+func (s *server) ChannelList(c *gin.Context) {
+	channels, err := s.daoOf(c).GetChannels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	for i := range channels {
+		redact(&channels[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"data": channels})
+}
+
+// redact the secrets of the channel, the settings are copied so the stored ones are kept.
+// the url of the chat webhooks is a secret itself, anyone knowing it can post to the chat.
+func redact(ch *model.ChannelEntity) {
+	if secretUrl(ch.Type) && ch.Url != "" {
+		ch.Url = redacted
+	}
+	if len(ch.Headers) > 0 {
+		headers := make(map[string]string, len(ch.Headers))
+		for k := range ch.Headers {
+			headers[k] = redacted
+		}
+		ch.Headers = headers
+	}
+	if ch.Smtp != nil && ch.Smtp.Password != "" {
+		smtp := *ch.Smtp
+		smtp.Password = redacted
+		ch.Smtp = &smtp
+	}
+}
+
+func secretUrl(channelType string) bool {
+	return channelType == model.SlackChannel || channelType == model.DingTalkChannel || channelType == model.FeishuChannel
+}
+
+// keepSecrets put back the secrets of prev the channel has as redacted. they are only kept for the same destination,
+// otherwise an update changing the url or the smtp server would send the saved secrets to it.
+func keepSecrets(ch *model.ChannelEntity, prev model.ChannelEntity) error {
+	if ch.Url == redacted {
+		if ch.Type != prev.Type || secretUrl(prev.Type) == false {
+			return errors.New("the url of the channel should be sent again")
+		}
+		ch.Url = prev.Url
+	}
+	for k, v := range ch.Headers {
+		if v != redacted {
+			continue
+		}
+		saved, ok := prev.Headers[k]
+		if ok == false || ch.Url != prev.Url {
+			return fmt.Errorf("the header %s should be sent again", k)
+		}
+		ch.Headers[k] = saved
+	}
+	if ch.Smtp != nil && ch.Smtp.Password == redacted {
+		if prev.Smtp == nil || ch.Smtp.Host != prev.Smtp.Host || ch.Smtp.Port != prev.Smtp.Port {
+			return errors.New("the smtp password should be sent again as the server changed")
+		}
+		ch.Smtp.Password = prev.Smtp.Password
+	}
+	return nil
+}
+
+func (s *server) CreateChannel(c *gin.Context) {
+	var ch model.ChannelEntity
+	if err := c.BindJSON(&ch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{})
+		return
+	}
+	if err := alert.CheckChannel(&ch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := s.daoOf(c).AddChannel(ch)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": id})
+}
+
+func (s *server) UpdateChannel(c *gin.Context) {
+	var ch model.ChannelEntity
+	if err := c.BindJSON(&ch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{})
+		return
+	}
+	ch.ChannelId = c.Param("channelId")
+	if prev, err := s.daoOf(c).GetChannel(ch.ChannelId); err == nil {
+		if err = keepSecrets(&ch, prev); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := alert.CheckChannel(&ch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.daoOf(c).UpdateChannel(ch); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func (s *server) RemoveChannel(c *gin.Context) {
+	id := c.Param("channelId")
+	rules, err := s.daoOf(c).GetAlertRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	for _, rule := range rules {
+		for _, chId := range rule.Channels {
+			if chId == id {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("the channel is used by rule %s", rule.Name)})
+				return
+			}
+		}
+	}
+	if err = s.daoOf(c).RemoveChannel(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// TestChannel send a sample alert through the channel.
+func (s *server) TestChannel(c *gin.Context) {
+	ch, err := s.daoOf(c).GetChannel(c.Param("channelId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	msg := &alert.Message{
+		Rule:    "test",
+		Trigger: model.TriggerFailure,
+		Title:   "test alert",
+		JobName: "test",
+		StartAt: time.Now(),
+		Error:   "this is a test alert, nothing failed",
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	if err = alert.Send(ctx, &ch, msg); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func (s *server) RuleList(c *gin.Context) {
+	rules, err := s.daoOf(c).GetAlertRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+func (s *server) CreateRule(c *gin.Context) {
+	var rule model.AlertRuleEntity
+	if err := c.BindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{})
+		return
+	}
+	if err := checkRule(s.daoOf(c), &rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := s.daoOf(c).AddAlertRule(rule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": id})
+}
+
+func (s *server) UpdateRule(c *gin.Context) {
+	var rule model.AlertRuleEntity
+	if err := c.BindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{})
+		return
+	}
+	rule.RuleId = c.Param("ruleId")
+	if err := checkRule(s.daoOf(c), &rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.daoOf(c).UpdateAlertRule(rule); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func (s *server) RemoveRule(c *gin.Context) {
+	if err := s.daoOf(c).RemoveAlertRule(c.Param("ruleId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// checkRule verify the settings and that the channels exist.
+func checkRule(d dao.Dao, rule *model.AlertRuleEntity) error {
+	if err := alert.CheckRule(rule); err != nil {
+		return err
+	}
+	for _, id := range rule.Channels {
+		if _, err := d.GetChannel(id); err != nil {
+			return fmt.Errorf("channel %s is not exists", id)
+		}
+	}
+	return nil
+}
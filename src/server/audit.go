@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+	"traitor/dao/model"
+	"traitor/logger"
+	"traitor/schedule"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// actor who made a change and from where.
+type actor struct {
+	name string
+	ip   string
+}
+
+func actorOf(c *gin.Context) actor {
+	name := principalOf(c).Name
+	if name == "" {
+		name = "anonymous" // the authentication is disabled.
+	}
+	return actor{name: name, ip: c.ClientIP()}
+}
+
+// snapshot the fields of the job compared by the audit, nil if the job is not exists.
+func (s *server) snapshot(ns string, id string) map[string]any {
+	d := s.dao.Namespace(ns)
+	j, err := d.GetJobInfo(id)
+	if err != nil {
+		return nil
+	}
+	if sc, err := d.GetJobScript(id); err == nil {
+		j.Script = sc.Script
+	}
+	return fields(j)
+}
+
+// fields the fields of the job compared by the audit.
+func fields(j model.JobEntity) map[string]any {
+	var mp map[string]any
+	buffer, _ := json.Marshal(j)
+	_ = json.Unmarshal(buffer, &mp)
+	delete(mp, model.JobId)
+	delete(mp, model.LastExecTime)
+	delete(mp, model.Revision)
+	delete(mp, "namespace")
+	return mp
+}
+
+// audit record a change of the job in its namespace, it's done already so an error is only logged.
+func (s *server) audit(ns string, a actor, action string, id string, before map[string]any, after map[string]any) {
+	err := s.dao.Namespace(ns).AddAudit(model.AuditEntity{
+		Time:    time.Now(),
+		Actor:   a.name,
+		Ip:      a.ip,
+		Action:  action,
+		JobId:   id,
+		Changes: diff(before, after),
+	})
+	if err != nil {
+		logger.With(logger.JobIdField, schedule.JobKey(ns, id)).Error("add audit error:", err)
+	}
+}
+
+// diff the changed fields, sorted by name.
+func diff(before map[string]any, after map[string]any) []model.Change {
+	fields := make([]string, 0, len(before)+len(after))
+	for k := range before {
+		fields = append(fields, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; ok == false {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	res := make([]model.Change, 0)
+	for _, field := range fields {
+		if reflect.DeepEqual(before[field], after[field]) == false {
+			res = append(res, model.Change{Field: field, Before: before[field], After: after[field]})
+		}
+	}
+	return res
+}
+
+// AuditList the entries filtered by jobId, actor, action and the RFC3339 since and until, newest first.
+func (s *server) AuditList(c *gin.Context) {
+	f := model.AuditFilter{
+		JobId:  c.Query("jobId"),
+		Actor:  c.Query("actor"),
+		Action: c.Query("action"),
+		Limit:  defaultAuditLimit,
+	}
+	for name, t := range map[string]**time.Time{"since": &f.Since, "until": &f.Until} {
+		if v := c.Query(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+			*t = &parsed
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxAuditLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		f.Limit = limit
+	}
+	entries, err := s.daoOf(c).GetAudits(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
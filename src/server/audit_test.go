@@ -0,0 +1,50 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"traitor/config"
+	"traitor/dao/model"
+)
+
+func Test_diff(t *testing.T) {
+	before := map[string]any{"cron": "0 0 * * * * *", "name": "a", "state": float64(0)}
+	after := map[string]any{"cron": "0 5 * * * * *", "name": "a", "script": "1"}
+	want := []model.Change{
+		{Field: "cron", Before: "0 0 * * * * *", After: "0 5 * * * * *"},
+		{Field: "script", After: "1"},
+		{Field: "state", Before: float64(0)},
+	}
+	if got := diff(before, after); reflect.DeepEqual(got, want) == false {
+		t.Errorf("diff() = %v, want %v", got, want)
+	}
+	if got := diff(nil, nil); len(got) != 0 {
+		t.Errorf("diff() = %v, want nothing", got)
+	}
+}
+
+func Test_actorOf_ip(t *testing.T) {
+	s, _ := newTestServer(t)
+	ipOf := func() string {
+		engine := gin.New()
+		s.RegistryRouting(engine)
+		engine.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, actorOf(c).ip) })
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.RemoteAddr = "10.0.0.1:4321"
+		req.Header.Set("X-Forwarded-For", "1.2.3.4")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+	if ip := ipOf(); ip != "10.0.0.1" {
+		t.Errorf("ip = %s, want the peer as no proxy is trusted", ip)
+	}
+	config.SetupConfig(config.TrustProxies, "10.0.0.0/8")
+	defer config.SetupConfig(config.TrustProxies, "")
+	if ip := ipOf(); ip != "1.2.3.4" {
+		t.Errorf("ip = %s, want the one forwarded by the trusted proxy", ip)
+	}
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"traitor/auth"
+	"traitor/config"
+	"traitor/dao"
+	"traitor/dao/model"
+	"traitor/logger"
+)
+
+const (
+	principalKey = "principal"
+	tokenCookie  = "traitor_token" // the UI keeps the token in it, so page loads and websockets carry it.
+)
+
+// makeAuth nil if the authentication is disabled.
+func makeAuth(d dao.Dao) *auth.Authenticator {
+	if config.GetConfig(config.AuthEnable) != "true" {
+		return nil
+	}
+	if users, _ := d.GetUsers(); len(users) == 0 && config.GetConfig(config.AdminToken) == "" &&
+		config.GetConfig(config.OidcIssuer) == "" {
+		logger.Warn("authentication is enabled without users, an admin token or an OIDC issuer, nobody can sign in.")
+	}
+	return auth.New(d, auth.Config{
+		AdminToken: config.GetConfig(config.AdminToken),
+		Oidc: auth.OidcConfig{
+			Issuer:         config.GetConfig(config.OidcIssuer),
+			Audience:       config.GetConfig(config.OidcAudience),
+			RoleClaim:      config.GetConfig(config.OidcRoleClaim),
+			NamespaceClaim: config.GetConfig(config.OidcNsClaim),
+		},
+	})
+}
+
+// authenticate the caller by the bearer token or the cookie of the UI.
+func (s *server) authenticate(c *gin.Context) {
+	if s.auth == nil {
+		return
+	}
+	bearer := ""
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		bearer = strings.TrimPrefix(header, "Bearer ")
+	} else {
+		bearer, _ = c.Cookie(tokenCookie)
+	}
+	p, err := s.auth.Authenticate(c.Request.Context(), bearer)
+	if err != nil {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"err": err.Error()})
+		return
+	}
+	c.Set(principalKey, p)
+}
+
+// require the role for the routes, it passes everything if the authentication is disabled.
+func (s *server) require(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.auth == nil {
+			return
+		}
+		if auth.Allows(principalOf(c).Role, role) == false {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"err": "the " + role + " role is required"})
+		}
+	}
+}
+
+func principalOf(c *gin.Context) auth.Principal {
+	p, _ := c.Get(principalKey)
+	principal, _ := p.(auth.Principal)
+	return principal
+}
+
+// checkOrigin accept the websockets from the same host or the origins of -allowOrigins.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true // not a browser.
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range config.GetConfigList(config.AllowOrigins) {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// Me who the caller is authenticated as.
+func (s *server) Me(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": principalOf(c), "auth": s.auth != nil})
+}
+
+func (s *server) UserList(c *gin.Context) {
+	users, err := s.dao.GetUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": users})
+}
+
+func (s *server) CreateUser(c *gin.Context) {
+	var u model.UserEntity
+	if err := c.BindJSON(&u); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{})
+		return
+	}
+	if u.Name == "" || auth.ValidRole(u.Role) == false {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and a valid role are required"})
+		return
+	}
+	if validNamespaces(u.Namespaces) == false {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid namespace"})
+		return
+	}
+	u.CreatedAt = time.Now()
+	if err := s.dao.AddUser(u); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// UpdateUser change the role and the namespaces, or disable the user.
+func (s *server) UpdateUser(c *gin.Context) {
+	var req struct {
+		Role       string   `json:"role"`
+		Disabled   bool     `json:"disabled"`
+		Namespaces []string `json:"namespaces"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{})
+		return
+	}
+	if auth.ValidRole(req.Role) == false || validNamespaces(req.Namespaces) == false {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role or namespace"})
+		return
+	}
+	u, err := s.dao.GetUser(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	u.Role, u.Disabled, u.Namespaces = req.Role, req.Disabled, req.Namespaces
+	if err = s.dao.UpdateUser(u); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// validNamespaces the namespaces of a user need not exist yet, they are granted by name.
+func validNamespaces(nss []string) bool {
+	for _, ns := range nss {
+		if model.ValidNamespace(ns) == false {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *server) RemoveUser(c *gin.Context) {
+	if err := s.dao.RemoveUser(c.Param("name")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func (s *server) TokenList(c *gin.Context) {
+	tokens, err := s.dao.GetTokens(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	for i := range tokens {
+		tokens[i].Hash = ""
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tokens})
+}
+
+// CreateToken the secret is only returned here.
+func (s *server) CreateToken(c *gin.Context) {
+	var req struct {
+		Name      string `json:"name"`
+		ExpiresIn int64  `json:"expiresIn,omitempty"` // days, 0 for never.
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{})
+		return
+	}
+	u, err := s.dao.GetUser(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	secret, hash := auth.NewToken()
+	t := model.TokenEntity{User: u.Name, Name: req.Name, Hash: hash, CreatedAt: time.Now()}
+	if req.ExpiresIn > 0 {
+		expires := t.CreatedAt.Add(time.Duration(req.ExpiresIn) * 24 * time.Hour)
+		t.ExpiresAt = &expires
+	}
+	id, err := s.dao.AddToken(t)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"tokenId": id, "token": secret}})
+}
+
+func (s *server) RemoveToken(c *gin.Context) {
+	if err := s.dao.RemoveToken(c.Param("tokenId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
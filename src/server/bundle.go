@@ -0,0 +1,300 @@
+package server
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"traitor/bundle"
+	"traitor/dao/model"
+	"traitor/schedule"
+)
+
+// how an import handles a job whose id is taken.
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictRename    = "rename" // import it under a free id.
+)
+
+// the actions of an import.
+const (
+	importCreate    = "create"
+	importUpdate    = "update"
+	importUnchanged = "unchanged"
+	importSkip      = "skip"
+	importRename    = "rename"
+	importInvalid   = "invalid"
+)
+
+// importItem what an import does, or would do, with a job of the bundle.
+type importItem struct {
+	Id      string         `json:"id,omitempty"` // in the bundle.
+	Name    string         `json:"name"`
+	Action  string         `json:"action"`
+	JobId   string         `json:"jobId,omitempty"` // the job created or updated.
+	Changes []model.Change `json:"changes,omitempty"`
+	Error   string         `json:"error,omitempty"`
+
+	entity model.JobEntity
+}
+
+// Export the bundle of the jobs selected by id, repeated or comma separated, and group, every job if neither.
+// it's json, or yaml with format=yaml.
+func (s *server) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "yaml" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format"})
+		return
+	}
+	ids := make(map[string]bool)
+	for _, v := range c.QueryArray("id") {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids[id] = false
+			}
+		}
+	}
+	group := c.Query("group")
+	d := s.daoOf(c)
+	jobs, err := d.GetJobInfos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	b := bundle.Bundle{Version: bundle.Version, ExportedAt: time.Now().UTC(), Namespace: namespaceOf(c), Jobs: make([]bundle.Job, 0)}
+	selected := len(ids) > 0
+	for _, j := range jobs {
+		if _, ok := ids[j.JobId]; (selected && ok == false) || (group != "" && j.Group != group) {
+			continue
+		}
+		ids[j.JobId] = true
+		sc, err := d.GetJobScript(j.JobId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+			return
+		}
+		j.Script = sc.Script
+		b.Jobs = append(b.Jobs, bundle.FromEntity(j))
+	}
+	for id, found := range ids {
+		if found == false {
+			c.JSON(http.StatusNotFound, gin.H{"err": "job " + id + " is not found"})
+			return
+		}
+	}
+	sort.Slice(b.Jobs, func(i, k int) bool {
+		if b.Jobs[i].Name != b.Jobs[k].Name {
+			return b.Jobs[i].Name < b.Jobs[k].Name
+		}
+		return b.Jobs[i].Id < b.Jobs[k].Id
+	})
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="traitor-%s.%s"`, b.Namespace, format))
+	if format == "yaml" {
+		c.YAML(http.StatusOK, b)
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
+// Import the jobs of a bundle, in json or yaml by the Content-Type, into the namespace.
+// conflict is skip, overwrite or rename for the jobs whose id is taken, with dryRun=true nothing is saved.
+// nothing is saved either if any job of the bundle is invalid.
+func (s *server) Import(c *gin.Context) {
+	conflict := c.DefaultQuery("conflict", conflictSkip)
+	if conflict != conflictSkip && conflict != conflictOverwrite && conflict != conflictRename {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid conflict"})
+		return
+	}
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dryRun"})
+		return
+	}
+	var b bundle.Bundle
+	if strings.Contains(c.ContentType(), "yaml") {
+		err = c.ShouldBindYAML(&b)
+	} else {
+		err = c.ShouldBindJSON(&b)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if b.Version > bundle.Version {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported bundle version " + strconv.Itoa(b.Version)})
+		return
+	}
+	ns := namespaceOf(c)
+	d := s.daoOf(c)
+	jobs, err := d.GetJobInfos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	existing := make(map[string]model.JobEntity, len(jobs))
+	for _, j := range jobs {
+		existing[j.JobId] = j
+	}
+	items, creates := s.planImport(ns, b, existing, conflict)
+	res := gin.H{"dryRun": dryRun, "data": items}
+	for _, item := range items {
+		if item.Action == importInvalid {
+			res["error"] = "the bundle has invalid jobs"
+			c.JSON(http.StatusBadRequest, res)
+			return
+		}
+	}
+	if n, err := s.dao.GetNamespace(ns); err == nil && n.MaxJobs > 0 && len(jobs)+creates > n.MaxJobs {
+		res["error"] = schedule.ErrQuotaExceeded.Error()
+		c.JSON(http.StatusForbidden, res)
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+	a := actorOf(c)
+	status := http.StatusOK
+	for i := range items {
+		item := &items[i]
+		if item.Action == importSkip || item.Action == importUnchanged {
+			continue
+		}
+		if _, err = s.saveJob(ns, a, model.AuditImport, item.Action == importUpdate, item.entity); err != nil {
+			item.Error = err.Error()
+			status = http.StatusInternalServerError
+		}
+	}
+	c.JSON(status, res)
+}
+
+// planImport what to do with every job of the bundle, and how many jobs it creates.
+func (s *server) planImport(ns string, b bundle.Bundle, existing map[string]model.JobEntity, conflict string) ([]importItem, int) {
+	items := make([]importItem, 0, len(b.Jobs))
+	taken := make(map[string]bool) // the ids of the bundle.
+	creates := 0
+	for _, bj := range b.Jobs {
+		item := importItem{Id: bj.Id, Name: bj.Name}
+		e, err := bj.Entity()
+		if err == nil {
+			err = checkJob(e)
+		}
+		if err == nil && bj.Id != "" && taken[bj.Id] {
+			err = fmt.Errorf("duplicate id %s", bj.Id)
+		}
+		taken[bj.Id] = true
+		if err != nil {
+			item.Action, item.Error = importInvalid, err.Error()
+			items = append(items, item)
+			continue
+		}
+		prev, conflicted := existing[e.JobId]
+		switch {
+		case e.JobId == "" || conflicted == false:
+			item.Action = importCreate
+		case conflict == conflictOverwrite && prev.ManagedBy != "":
+			item.Action, item.Error = importInvalid, errManaged(prev).Error()
+		case conflict == conflictSkip:
+			item.Action = importSkip
+		case conflict == conflictRename:
+			item.Action = importRename
+			e.JobId = freeId(e.JobId, existing, taken)
+			taken[e.JobId] = true
+		default:
+			before := s.snapshot(ns, prev.JobId)
+			item.Changes = diff(before, fields(e))
+			item.Action = importUpdate
+			if len(item.Changes) == 0 {
+				item.Action = importUnchanged
+			}
+		}
+		if item.Action == importCreate || item.Action == importRename {
+			creates++
+		}
+		item.JobId = e.JobId
+		item.entity = e
+		items = append(items, item)
+	}
+	return items, creates
+}
+
+// checkJob the settings of a job to be saved, the time of a stopped job is checked when it's enabled.
+func checkJob(e model.JobEntity) error {
+	if err := checkJobId(e.JobId); err != nil {
+		return err
+	}
+	if e.State == model.Runnable || e.ExecType == model.TimingExecute {
+		if err := checkTimeSettings(e.ExecType, e); err != nil {
+			return err
+		}
+	} else if e.ExecAt == nil {
+		return fmt.Errorf("invalid exec time")
+	}
+	return checkTaskSettings(e)
+}
+
+// freeId the id followed by the first number not taken.
+func freeId(id string, existing map[string]model.JobEntity, taken map[string]bool) string {
+	for i := 2; ; i++ {
+		candidate := id + "-" + strconv.Itoa(i)
+		if _, ok := existing[candidate]; ok == false && taken[candidate] == false {
+			return candidate
+		}
+	}
+}
+
+// saveJob create the job, or overwrite the existing one, and schedule it by its state.
+func (s *server) saveJob(ns string, a actor, action string, exists bool, j model.JobEntity) (string, error) {
+	d := s.dao.Namespace(ns)
+	j.LastExecTime = nil
+	j.Revision = time.Now().UnixNano()
+	id := j.JobId
+	if exists == false {
+		var err error
+		if id, err = d.AddJob(j); err != nil {
+			return id, err
+		}
+		s.audit(ns, a, action, id, nil, s.snapshot(ns, id))
+	} else {
+		before := s.snapshot(ns, id)
+		mp := map[string]any{
+			model.Name:        j.Name,
+			model.Description: j.Description,
+			model.Group:       j.Group,
+			model.Cron:        j.Cron,
+			model.ExecType:    j.ExecType,
+			model.State:       j.State,
+			model.TaskType:    j.TaskType,
+			model.Script:      j.Script,
+			model.Revision:    j.Revision,
+			model.Params:      j.Params,
+			model.ManagedBy:   j.ManagedBy,
+		}
+		if j.Params == nil { // cleared, nil is not saved.
+			mp[model.Params] = map[string]any{}
+		}
+		// the settings of another type are left, they're not used.
+		if j.ExecAt != nil {
+			mp[model.ExecAt] = j.ExecAt
+		}
+		if j.Command != nil {
+			mp[model.CommandField] = j.Command
+		}
+		if j.Http != nil {
+			mp[model.HttpField] = j.Http
+		}
+		if err := d.UpdateJob(id, mp); err != nil {
+			return id, err
+		}
+		s.schedule.InvalidateScript(schedule.JobKey(ns, id))
+		s.audit(ns, a, action, id, before, s.snapshot(ns, id))
+	}
+	if exists || j.State == model.Runnable {
+		s.schedule.HandleJobStateChange(schedule.JobKey(ns, id), j.State)
+	}
+	return id, nil
+}
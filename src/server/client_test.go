@@ -0,0 +1,249 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"traitor/auth"
+	"traitor/client"
+	"traitor/config"
+	"traitor/dao/model"
+	dbconfig "traitor/db/config"
+	"traitor/js_module/debugger"
+	"traitor/schedule"
+)
+
+const testAdminToken = "test-admin-token"
+
+// testServer the routes of a standalone server backed by a fresh local db.
+func testServer(t *testing.T) *client.Client {
+	_, c := newTestServer(t)
+	return c
+}
+
+// newTestServer the server of testServer, with its client.
+func newTestServer(t *testing.T) (*server, *client.Client) {
+	dbconfig.Properties.AppendFilename = t.TempDir() + "/appendonly.aof"
+	config.SetupConfig(config.CommandEnable, "true")
+	config.SetupConfig(config.CommandAllow, "echo")
+	sc, d := schedule.StartStandalone()
+	t.Cleanup(sc.Close)
+	s := &server{
+		schedule: sc,
+		dao:      d,
+		upgrade:  websocket.Upgrader{CheckOrigin: checkOrigin},
+		auth:     auth.New(d, auth.Config{AdminToken: testAdminToken}),
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	s.RegistryRouting(engine)
+	ts := httptest.NewServer(engine)
+	t.Cleanup(ts.Close)
+	return s, client.New(ts.URL, testAdminToken)
+}
+
+func TestClient(t *testing.T) {
+	s, c := newTestServer(t)
+	ctx := context.Background()
+
+	if p, enabled, err := c.Me(ctx); err != nil || enabled == false || p.Role != auth.Admin {
+		t.Fatalf("Me() = %v, %v, %v", p, enabled, err)
+	}
+	if _, err := client.New(c.BaseUrl(), "wrong").JobList(ctx); err == nil {
+		t.Fatal("JobList() with a wrong token succeeded")
+	}
+
+	job := model.JobEntity{
+		Name:     "echo",
+		Cron:     "0 0 0 1 1 * 2099",
+		Group:    "test",
+		TaskType: model.CommandTask,
+		Command:  &model.Command{Args: []string{"echo", "hello"}},
+		// only the sync manages a job, the API ignores it.
+		ManagedBy: "jobs/echo.job.yaml",
+	}
+	id, err := c.CreateJob(ctx, client.Timing, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.CreateJob(ctx, client.Timing, model.JobEntity{Cron: "bad"}); err == nil || err.Error() != "traitor: 400 invalid cron expression" {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	// the id of a job is not taken again, it would overwrite the job.
+	dup := model.JobEntity{JobId: id, Name: "dup", Cron: job.Cron}
+	var e *client.Error
+	if _, err = c.CreateJob(ctx, client.Timing, dup); errors.As(err, &e) == false || e.StatusCode != http.StatusConflict {
+		t.Fatalf("CreateJob() of an existing id = %v, want 409", err)
+	}
+	if _, err = c.RunJob(ctx, client.Timing, dup); errors.As(err, &e) == false || e.StatusCode != http.StatusConflict {
+		t.Fatalf("RunJob() of an existing id = %v, want 409", err)
+	}
+	if _, err = s.dao.AddJob(dup); err == nil {
+		t.Fatal("AddJob() of an existing id succeeded")
+	}
+	if err = c.UpdateJob(ctx, id, map[string]any{"cron": job.Cron, "description": "says hello", "managedBy": job.ManagedBy}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.GetJob(ctx, id)
+	if err != nil || got.Name != "echo" || got.Description != "says hello" || got.Group != "test" || got.ManagedBy != "" {
+		t.Fatalf("GetJob() = %v, %v", got, err)
+	}
+	if _, err = c.GetJob(ctx, "missing"); client.IsNotFound(err) == false {
+		t.Fatalf("GetJob() error = %v, want not found", err)
+	}
+	if err = c.EnableJob(ctx, id, true); err != nil {
+		t.Fatal(err)
+	}
+	page, err := c.Jobs(ctx, client.JobQuery{Q: "hello", State: "RUNNABLE"})
+	if err != nil || len(page.Data) != 1 || page.Data[0].JobId != id || page.Data[0].NextFireTime == nil {
+		t.Fatalf("Jobs() = %v, %v", page, err)
+	}
+	if err = c.UpdateScript(ctx, id, "// unused"); err != nil {
+		t.Fatal(err)
+	}
+	if sc, err := c.GetScript(ctx, id); err != nil || sc != "// unused" {
+		t.Fatalf("GetScript() = %q, %v", sc, err)
+	}
+	// the script is only changed with its revision.
+	if err = c.UpdateJob(ctx, id, map[string]any{"cron": job.Cron, "script": "// changed"}); err != nil {
+		t.Fatal(err)
+	}
+	if sc, err := c.GetScript(ctx, id); err != nil || sc != "// unused" {
+		t.Fatalf("GetScript() after UpdateJob() = %q, %v", sc, err)
+	}
+
+	runId, err := c.TriggerJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var run model.RunEntity
+	for i := 0; i < 50 && (run.RunId == "" || run.State == model.RunRunning); i++ {
+		time.Sleep(100 * time.Millisecond)
+		run, _ = c.GetRun(ctx, runId)
+	}
+	if run.State != model.RunSuccess || run.Stdout != "hello\n" {
+		t.Fatalf("the run = %v", run)
+	}
+	if err = c.StreamRun(ctx, run.RunId, func(string) {}); err != nil { // a finished run ends the stream at once.
+		t.Fatal(err)
+	}
+	if _, err = c.GetRun(ctx, "missing"); client.IsNotFound(err) == false {
+		t.Fatalf("GetRun() error = %v, want not found", err)
+	}
+
+	if err = c.CreateNamespace(ctx, model.NamespaceEntity{Name: "team-a", MaxJobs: 1}); err != nil {
+		t.Fatal(err)
+	}
+	a := c.WithNamespace("team-a")
+	aId, err := a.CreateJob(ctx, client.Timing, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the job of team-a is kept under the key ns_team-a:<id>, the default namespace could not reach it.
+	foreign := job
+	foreign.JobId = "ns_team-a:" + aId
+	if _, err = c.CreateJob(ctx, client.Timing, foreign); err == nil {
+		t.Fatal("CreateJob() with the key of another namespace succeeded")
+	}
+	if _, err = c.GetJob(ctx, foreign.JobId); client.IsNotFound(err) == false {
+		t.Fatalf("GetJob() of the key of another namespace = %v, want not found", err)
+	}
+	if err = c.UpdateScript(ctx, foreign.JobId, "// foreign"); err == nil {
+		t.Fatal("UpdateScript() of the key of another namespace succeeded")
+	}
+	if _, err = a.CreateJob(ctx, client.Timing, job); err == nil {
+		t.Fatal("CreateJob() over the quota succeeded")
+	}
+	if jobs, err := a.JobList(ctx); err != nil || len(jobs) != 1 || jobs[0].Namespace != "team-a" {
+		t.Fatalf("JobList() = %v, %v", jobs, err)
+	}
+
+	hook := model.ChannelEntity{Name: "hook", Type: model.WebhookChannel, Url: "http://127.0.0.1:1/", Headers: map[string]string{"Authorization": "secret"}}
+	chId, err := c.CreateChannel(ctx, hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	channels, err := c.Channels(ctx)
+	if err != nil || len(channels) != 1 || channels[0].Headers["Authorization"] != redacted {
+		t.Fatalf("Channels() = %v, %v", channels, err)
+	}
+	// the listed channel is saved back, the secret is kept.
+	channels[0].Name = "renamed"
+	if err = c.UpdateChannel(ctx, chId, channels[0]); err != nil {
+		t.Fatal(err)
+	}
+	if ch, err := s.dao.GetChannel(chId); err != nil || ch.Name != "renamed" || ch.Headers["Authorization"] != "secret" {
+		t.Fatalf("GetChannel() = %v, %v", ch, err)
+	}
+	// nor sent to another url.
+	channels[0].Url = "http://127.0.0.1:2/"
+	if err = c.UpdateChannel(ctx, chId, channels[0]); err == nil {
+		t.Fatal("UpdateChannel() of another url with the redacted header succeeded")
+	}
+	slack, err := c.CreateChannel(ctx, model.ChannelEntity{Name: "slack", Type: model.SlackChannel, Url: "https://hooks.slack.com/services/secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if channels, err = c.Channels(ctx); err != nil || len(channels) != 2 {
+		t.Fatalf("Channels() = %v, %v", channels, err)
+	}
+	if channels[0].ChannelId != slack {
+		channels[0] = channels[1]
+	}
+	if channels[0].Url != redacted {
+		t.Fatalf("url of the slack channel = %s, want it redacted", channels[0].Url)
+	}
+	if err = c.UpdateChannel(ctx, slack, channels[0]); err != nil {
+		t.Fatal(err)
+	}
+	if ch, err := s.dao.GetChannel(slack); err != nil || ch.Url != "https://hooks.slack.com/services/secret" {
+		t.Fatalf("GetChannel() = %v, %v", ch, err)
+	}
+	if _, err = c.CreateRule(ctx, model.AlertRuleEntity{Name: "failed", Trigger: model.TriggerFailure, Channels: []string{chId}}); err != nil {
+		t.Fatal(err)
+	}
+	if rules, err := c.Rules(ctx); err != nil || len(rules) != 1 {
+		t.Fatalf("Rules() = %v, %v", rules, err)
+	}
+
+	entries, err := c.Audit(ctx, model.AuditFilter{JobId: id})
+	if err != nil || len(entries) == 0 || entries[len(entries)-1].Action != model.AuditCreate {
+		t.Fatalf("Audit() = %v, %v", entries, err)
+	}
+	b, err := c.Export(ctx, []string{id}, "")
+	if err != nil || len(b.Jobs) != 1 || b.Jobs[0].Command == nil || b.Jobs[0].Task != "COMMAND" {
+		t.Fatalf("Export() = %v, %v", b, err)
+	}
+	result, err := a.Import(ctx, b, client.ConflictSkip, true)
+	if err == nil || len(result.Items) != 1 || result.Items[0].Action != "create" {
+		t.Fatalf("Import() over the quota = %v, %v", result, err)
+	}
+	if result, err = c.Import(ctx, b, client.ConflictRename, false); err != nil || result.Items[0].Action != "rename" {
+		t.Fatalf("Import() = %v, %v", result, err)
+	}
+	if got, err := c.GetJob(ctx, result.Items[0].JobId); err != nil || got.Command.Args[1] != "hello" || got.State != model.Runnable {
+		t.Fatalf("GetJob() of the imported job = %v, %v", got, err)
+	}
+	b.Jobs[0].Task = "SHELL"
+	if result, err = c.Import(ctx, b, client.ConflictOverwrite, true); err == nil || result.Items[0].Action != "invalid" {
+		t.Fatalf("Import() of an invalid job = %v, %v", result, err)
+	}
+	var output string
+	err = c.Debug(ctx, id, `console.log("hi " + params.who)`, map[string]any{"who": "bob"}, func(e debugger.Event) {
+		output += e.Text
+	})
+	if err != nil || output != "hi bob" {
+		t.Fatalf("Debug() = %q, %v", output, err)
+	}
+	if err = c.RemoveJob(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Ready(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"testing"
+	"traitor/client"
+	"traitor/dao/model"
+	"traitor/js_module/debugger"
+)
+
+func TestDebug_promote(t *testing.T) {
+	c := testServer(t)
+	ctx := context.Background()
+	id, err := c.CreateJob(ctx, client.Timing, model.JobEntity{Name: "draft", Cron: "0 0 0 1 1 * 2099"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.UpdateScript(ctx, id, "// saved"); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := c.GetJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := "ws" + strings.TrimPrefix(c.BaseUrl(), "http") + "/api/debug?protocol=json&id=" + id
+	ws, _, err := websocket.DefaultDialer.Dial(u, http.Header{"Authorization": {"Bearer " + testAdminToken}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	const draft = `console.log("draft " + params.n)`
+	if err = ws.WriteJSON(debugger.Command{Cmd: debugger.CmdStart, Script: draft, Params: map[string]any{"n": 1}}); err != nil {
+		t.Fatal(err)
+	}
+	// the draft runs instead of the saved script.
+	var output string
+	for {
+		var e debugger.Event
+		if err = ws.ReadJSON(&e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Event == debugger.EventFinished {
+			break
+		}
+		output += e.Text
+	}
+	if output != "draft 1" {
+		t.Fatalf("the output of the draft = %q", output)
+	}
+	if sc, err := c.GetScript(ctx, id); err != nil || sc != "// saved" {
+		t.Fatalf("the draft should not be saved by running it: %q, %v", sc, err)
+	}
+
+	if err = ws.WriteJSON(debugger.Command{Cmd: debugger.CmdPromote}); err != nil {
+		t.Fatal(err)
+	}
+	var e debugger.Event
+	if err = ws.ReadJSON(&e); err != nil || e.Event != debugger.EventPromoted {
+		t.Fatalf("promote = %v, %v", e, err)
+	}
+	if sc, err := c.GetScript(ctx, id); err != nil || sc != draft {
+		t.Fatalf("GetScript() after promote = %q, %v", sc, err)
+	}
+	promoted, err := c.GetJob(ctx, id)
+	if err != nil || promoted.Revision != e.Revision || promoted.Revision <= saved.Revision {
+		t.Fatalf("the revision after promote = %v, event %d, before %d", promoted.Revision, e.Revision, saved.Revision)
+	}
+	entries, err := c.Audit(ctx, model.AuditFilter{JobId: id, Action: model.AuditScript})
+	if err != nil || len(entries) != 2 || entries[0].Actor == "" {
+		t.Fatalf("Audit() = %v, %v", entries, err)
+	}
+	changed := false
+	for _, ch := range entries[0].Changes {
+		changed = changed || (ch.Field == model.Script && ch.After == draft)
+	}
+	if changed == false {
+		t.Errorf("the audit entry of promote = %v", entries[0])
+	}
+}
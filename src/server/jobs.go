@@ -0,0 +1,175 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"traitor/dao/model"
+	"traitor/schedule"
+)
+
+const (
+	defaultJobLimit = 50
+	maxJobLimit     = 500
+)
+
+// jobItem a job of the listing.
+type jobItem struct {
+	model.JobEntity
+	NextFireTime *time.Time `json:"nextFireTime"`
+}
+
+// jobCursor the last item of a page, the next page starts after it.
+type jobCursor struct {
+	Name  string `json:"name"`
+	Next  int64  `json:"next,omitempty"` // unix nano of the next fire time, 0 for never.
+	JobId string `json:"jobId"`
+	// Now unix nano of the time the next fire times of the first page are computed from, the following pages
+	// reuse it so the jobs are not moved between the pages as the time goes by.
+	Now int64 `json:"now,omitempty"`
+}
+
+// jobQuery the filters, order and page of the listing, a nil filter matches any job.
+type jobQuery struct {
+	keyword  string
+	group    string
+	state    *uint8
+	execType *uint8
+	taskType *uint8
+	sort     string // name or next.
+	desc     bool
+	limit    int
+	after    *jobCursor
+}
+
+var (
+	jobStates = map[string]uint8{"RUNNABLE": model.Runnable, "STOP": model.Stop}
+	execTypes = map[string]uint8{"TIMING": model.TimingExecute, "DELAY": model.DelayExecute}
+	taskTypes = map[string]uint8{"SCRIPT": model.ScriptTask, "COMMAND": model.CommandTask, "HTTP": model.HttpTask}
+)
+
+func parseJobQuery(c *gin.Context) (jobQuery, error) {
+	q := jobQuery{
+		keyword: strings.ToLower(c.Query("q")),
+		group:   c.Query("group"),
+		sort:    strings.TrimPrefix(c.DefaultQuery("sort", "name"), "-"),
+		desc:    strings.HasPrefix(c.Query("sort"), "-"),
+		limit:   defaultJobLimit,
+	}
+	for name, f := range map[string]struct {
+		values map[string]uint8
+		field  **uint8
+	}{"state": {jobStates, &q.state}, "type": {execTypes, &q.execType}, "taskType": {taskTypes, &q.taskType}} {
+		if v := c.Query(name); v != "" {
+			value, ok := f.values[strings.ToUpper(v)]
+			if ok == false {
+				return q, errors.New("invalid " + name)
+			}
+			*f.field = &value
+		}
+	}
+	if q.sort != "name" && q.sort != "next" {
+		return q, errors.New("invalid sort")
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxJobLimit {
+			return q, errors.New("invalid limit")
+		}
+		q.limit = limit
+	}
+	if v := c.Query("cursor"); v != "" {
+		buffer, err := base64.RawURLEncoding.DecodeString(v)
+		q.after = &jobCursor{}
+		if err != nil || json.Unmarshal(buffer, q.after) != nil {
+			return q, errors.New("invalid cursor")
+		}
+	}
+	return q, nil
+}
+
+func (q *jobQuery) match(j *model.JobEntity) bool {
+	return (q.keyword == "" || strings.Contains(strings.ToLower(j.Name), q.keyword) ||
+		strings.Contains(strings.ToLower(j.Description), q.keyword)) &&
+		(q.group == "" || j.Group == q.group) &&
+		(q.state == nil || j.State == *q.state) &&
+		(q.execType == nil || j.ExecType == *q.execType) &&
+		(q.taskType == nil || j.TaskType == *q.taskType)
+}
+
+func cursorOf(item *jobItem) jobCursor {
+	cur := jobCursor{Name: item.Name, JobId: item.JobId}
+	if item.NextFireTime != nil {
+		cur.Next = item.NextFireTime.UnixNano()
+	}
+	return cur
+}
+
+// less the order of the listing, the jobs never firing are the last by next fire time.
+func (q *jobQuery) less(a jobCursor, b jobCursor) bool {
+	if q.desc {
+		a, b = b, a
+	}
+	if q.sort == "next" && a.Next != b.Next {
+		return b.Next == 0 || (a.Next != 0 && a.Next < b.Next)
+	}
+	if q.sort == "name" && a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.JobId < b.JobId
+}
+
+// page the matched jobs in order after the cursor, and the cursor of the next page, empty for the last page.
+func (q *jobQuery) page(jobs []model.JobEntity, now time.Time) ([]jobItem, string) {
+	if q.after != nil && q.after.Now != 0 {
+		now = time.Unix(0, q.after.Now)
+	}
+	items := make([]jobItem, 0)
+	for _, j := range jobs {
+		if q.match(&j) {
+			items = append(items, jobItem{JobEntity: j, NextFireTime: schedule.NextFire(&j, now)})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return q.less(cursorOf(&items[i]), cursorOf(&items[j]))
+	})
+	if q.after != nil {
+		start := sort.Search(len(items), func(i int) bool {
+			return q.less(*q.after, cursorOf(&items[i]))
+		})
+		items = items[start:]
+	}
+	if len(items) <= q.limit {
+		return items, ""
+	}
+	items = items[:q.limit]
+	cur := cursorOf(&items[q.limit-1])
+	if q.sort == "next" {
+		cur.Now = now.UnixNano()
+	}
+	buffer, _ := json.Marshal(cur)
+	return items, base64.RawURLEncoding.EncodeToString(buffer)
+}
+
+// Jobs the jobs of the namespace, filtered by ?q= on name and description, state, type, taskType and group,
+// sorted by name or next fire time and paged by the cursor of the previous page.
+func (s *server) Jobs(c *gin.Context) {
+	q, err := parseJobQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	jobs, err := s.daoOf(c).GetJobInfos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	items, next := q.page(jobs, time.Now())
+	c.JSON(http.StatusOK, gin.H{"data": items, "next": next})
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+	"traitor/dao/model"
+)
+
+func Test_jobQuery_page(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.Local)
+	jobs := []model.JobEntity{
+		{JobId: "1", Name: "b", Cron: "0 0 * * * * *", State: model.Runnable}, // hourly.
+		{JobId: "2", Name: "a", Cron: "0 * * * * * *", State: model.Runnable}, // every minute.
+		{JobId: "3", Name: "c", Cron: "0 * * * * * *", State: model.Stop, Description: "Backup"},
+	}
+	q := jobQuery{sort: "next", limit: 2}
+	items, next := q.page(jobs, now)
+	if len(items) != 2 || items[0].JobId != "2" || items[1].JobId != "1" || next == "" {
+		t.Fatalf("page() = %v, %v", items, next)
+	}
+	if items[0].NextFireTime == nil || items[0].NextFireTime.Equal(now.Add(time.Minute)) == false {
+		t.Errorf("nextFireTime = %v", items[0].NextFireTime)
+	}
+	q.after = &jobCursor{Name: items[1].Name, Next: items[1].NextFireTime.UnixNano(), JobId: items[1].JobId}
+	items, next = q.page(jobs, now)
+	if len(items) != 1 || items[0].JobId != "3" || items[0].NextFireTime != nil || next != "" {
+		t.Errorf("the last page = %v, %v", items, next)
+	}
+	// the next page is computed from the time of the first one, not from when it's asked.
+	q = jobQuery{sort: "next", limit: 1}
+	items, next = q.page(jobs, now)
+	buffer, _ := base64.RawURLEncoding.DecodeString(next)
+	q.after = &jobCursor{}
+	if err := json.Unmarshal(buffer, q.after); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ = q.page(jobs, now.Add(2*time.Hour)); len(items) != 1 || items[0].JobId != "1" ||
+		items[0].NextFireTime.Equal(now.Add(time.Hour)) == false {
+		t.Errorf("the page after an hour = %v", items)
+	}
+
+	q = jobQuery{sort: "name", desc: true, keyword: "backup", limit: 10}
+	if items, _ = q.page(jobs, now); len(items) != 1 || items[0].JobId != "3" {
+		t.Errorf("search = %v", items)
+	}
+	q = jobQuery{sort: "name", desc: true, limit: 10}
+	if items, _ = q.page(jobs, now); items[0].Name != "c" || items[2].Name != "a" {
+		t.Errorf("sort by name desc = %v", items)
+	}
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+	"traitor/logger"
+)
+
+// requestLog log the api requests with the job and run they target, failed requests as errors.
+// the id of the request is only logged as the job if it exists, since a job has its own log file.
+func (s *server) requestLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	log := logger.With("method", c.Request.Method, "path", c.FullPath(),
+		"status", c.Writer.Status(), "latency", time.Since(start))
+	if id := c.Query("id"); id != "" {
+		if _, err := s.daoOf(c).GetJobInfo(id); err == nil {
+			log = log.With(logger.JobIdField, id)
+		} else {
+			log = log.With("id", id)
+		}
+	}
+	if runId := c.Param("runId"); runId != "" {
+		log = log.With("runId", runId)
+	}
+	if c.Writer.Status() >= http.StatusInternalServerError {
+		log.Error("request failed")
+	} else {
+		log.Debug("request")
+	}
+}
@@ -0,0 +1,16 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"time"
+	"traitor/metrics"
+)
+
+// requestMetrics observe the latency of the api requests by route.
+func requestMetrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	metrics.RequestDuration.WithLabelValues(c.Request.Method, c.FullPath(), strconv.Itoa(c.Writer.Status())).
+		Observe(time.Since(start).Seconds())
+}
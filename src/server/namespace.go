@@ -0,0 +1,150 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+	"traitor/dao"
+	"traitor/dao/model"
+	"traitor/schedule"
+)
+
+const (
+	namespaceKey    = "namespace"
+	namespaceHeader = "X-Namespace"
+)
+
+// scope the request to the namespace of the X-Namespace header or the ?namespace= query, default if neither.
+func (s *server) scope(c *gin.Context) {
+	ns := c.GetHeader(namespaceHeader)
+	if ns == "" {
+		ns = c.Query(namespaceKey)
+	}
+	if ns == "" {
+		ns = model.DefaultNamespace
+	}
+	if _, err := s.dao.GetNamespace(ns); err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": "namespace " + ns + " is not exists"})
+		return
+	}
+	if s.auth != nil && principalOf(c).CanAccess(ns) == false {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"err": "no access to the namespace " + ns})
+		return
+	}
+	c.Set(namespaceKey, ns)
+}
+
+func namespaceOf(c *gin.Context) string {
+	if ns := c.GetString(namespaceKey); ns != "" {
+		return ns
+	}
+	return model.DefaultNamespace
+}
+
+// daoOf the dao of the request's namespace.
+func (s *server) daoOf(c *gin.Context) dao.Dao {
+	return s.dao.Namespace(namespaceOf(c))
+}
+
+// keyOf the schedule key of the job in the request's namespace.
+func keyOf(c *gin.Context, id string) string {
+	return schedule.JobKey(namespaceOf(c), id)
+}
+
+// checkJobQuota whether another job could be added to the request's namespace.
+func (s *server) checkJobQuota(c *gin.Context) bool {
+	n, err := s.dao.GetNamespace(namespaceOf(c))
+	if err != nil || n.MaxJobs <= 0 {
+		return true
+	}
+	jobs, err := s.daoOf(c).GetJobInfos()
+	if err != nil {
+		return true
+	}
+	if len(jobs) >= n.MaxJobs {
+		c.JSON(http.StatusForbidden, gin.H{"error": schedule.ErrQuotaExceeded.Error()})
+		return false
+	}
+	return true
+}
+
+// NamespaceList the namespaces the caller could access.
+func (s *server) NamespaceList(c *gin.Context) {
+	nss, err := s.dao.GetNamespaces()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	res := make([]model.NamespaceEntity, 0, len(nss))
+	for _, n := range nss {
+		if s.auth == nil || principalOf(c).CanAccess(n.Name) {
+			res = append(res, n)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": res})
+}
+
+func (s *server) CreateNamespace(c *gin.Context) {
+	var n model.NamespaceEntity
+	if err := c.BindJSON(&n); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{})
+		return
+	}
+	if model.ValidNamespace(n.Name) == false || n.MaxJobs < 0 || n.MaxConcurrentRuns < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid name or quota"})
+		return
+	}
+	n.CreatedAt = time.Now()
+	if err := s.dao.AddNamespace(n); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// UpdateNamespace change the description and the quotas.
+func (s *server) UpdateNamespace(c *gin.Context) {
+	var req model.NamespaceEntity
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{})
+		return
+	}
+	if req.MaxJobs < 0 || req.MaxConcurrentRuns < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quota"})
+		return
+	}
+	n, err := s.dao.GetNamespace(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	n.Description, n.MaxJobs, n.MaxConcurrentRuns = req.Description, req.MaxJobs, req.MaxConcurrentRuns
+	if err = s.dao.UpdateNamespace(n); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// RemoveNamespace only an empty namespace could be removed.
+func (s *server) RemoveNamespace(c *gin.Context) {
+	name := c.Param("name")
+	if name == model.DefaultNamespace {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the default namespace cannot be removed"})
+		return
+	}
+	jobs, err := s.dao.Namespace(name).GetJobInfos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	if len(jobs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the namespace still has jobs"})
+		return
+	}
+	if err = s.dao.RemoveNamespace(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
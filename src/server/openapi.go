@@ -0,0 +1,17 @@
+package server
+
+import (
+	_ "embed"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// openApiDoc the OpenAPI 3 document of every route of RegistryRouting, kept by hand with the routes.
+//
+//go:embed openapi.json
+var openApiDoc []byte
+
+// OpenApi serve the OpenAPI document.
+func OpenApi(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openApiDoc)
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOpenApi_routes(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(openApiDoc, &doc); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	(&server{}).RegistryRouting(engine)
+	param := regexp.MustCompile(`:(\w+)`)
+	described := make(map[string]bool)
+	for _, r := range engine.Routes() {
+		p := param.ReplaceAllString(r.Path, "{$1}")
+		method := strings.ToLower(r.Method)
+		if _, ok := doc.Paths[p][method]; ok == false {
+			t.Errorf("%s %s is not described", r.Method, p)
+		}
+		described[method+" "+p] = true
+	}
+	for p, methods := range doc.Paths {
+		for method := range methods {
+			if described[method+" "+p] == false {
+				t.Errorf("%s %s is described but not routed", method, p)
+			}
+		}
+	}
+}
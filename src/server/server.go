@@ -7,18 +7,25 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorhill/cronexpr"
 	"github.com/gorilla/websocket"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"traitor/auth"
+	"traitor/config"
 	"traitor/dao"
 	"traitor/dao/model"
+	"traitor/js_module"
+	"traitor/js_module/debugger"
+	"traitor/metrics"
 	"traitor/schedule"
 )
 
 func (s *server) JobList(c *gin.Context) {
 
-	jobs, err := s.dao.GetJobInfos()
+	jobs, err := s.daoOf(c).GetJobInfos()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err.Error())
 		return
@@ -27,12 +34,18 @@ func (s *server) JobList(c *gin.Context) {
 }
 func (s *server) Remove(c *gin.Context) {
 	id := c.Query("id")
-	s.schedule.Remove(id)
-	err := s.dao.RemoveJob(id)
+	if j, err := s.daoOf(c).GetJobInfo(id); err == nil && s.checkUnmanaged(c, j) == false {
+		return
+	}
+	ns := namespaceOf(c)
+	before := s.snapshot(ns, id)
+	s.schedule.Remove(keyOf(c, id))
+	err := s.daoOf(c).RemoveJob(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err.Error())
 		return
 	}
+	s.audit(ns, actorOf(c), model.AuditRemove, id, before, nil)
 	c.JSON(http.StatusOK, gin.H{})
 }
 
@@ -59,15 +72,25 @@ func (s *server) Update(c *gin.Context) {
 	delete(mp, model.State)
 	delete(mp, model.LastExecTime)
 	delete(mp, model.JobId)
+	delete(mp, model.Revision)
+	// only the sync manages a job, and the script is changed by UpdateScript, which bumps the revision.
+	delete(mp, model.ManagedBy)
+	delete(mp, model.Script)
+	if _, ok := mp[model.ExecAt]; ok { // saved as the time, not the epoch millis of the json.
+		mp[model.ExecAt] = job.ExecAt
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{})
 		return
 	}
-	entity, err := s.dao.GetJobInfo(id)
+	entity, err := s.daoOf(c).GetJobInfo(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{})
 		return
 	}
+	if s.checkUnmanaged(c, entity) == false {
+		return
+	}
 	var execType uint8
 	// if job type would be changed.
 	if _, ok := mp[model.ExecType]; ok {
@@ -82,12 +105,30 @@ func (s *server) Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err = s.dao.UpdateJob(id, mp)
+	// check task settings with the merged job.
+	if _, ok := mp[model.TaskType]; ok == false {
+		job.TaskType = entity.TaskType
+	}
+	if _, ok := mp[model.CommandField]; ok == false {
+		job.Command = entity.Command
+	}
+	if _, ok := mp[model.HttpField]; ok == false {
+		job.Http = entity.Http
+	}
+	err = checkTaskSettings(job)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ns := namespaceOf(c)
+	before := s.snapshot(ns, id)
+	err = s.daoOf(c).UpdateJob(id, mp)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err.Error())
 		return
 	}
-	s.schedule.HandleJobTimeChange(id)
+	s.audit(ns, actorOf(c), model.AuditUpdate, id, before, s.snapshot(ns, id))
+	s.schedule.HandleJobTimeChange(keyOf(c, id))
 	c.JSON(http.StatusOK, gin.H{})
 }
 func (s *server) Create(c *gin.Context) {
@@ -107,15 +148,29 @@ func (s *server) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	err = checkTaskSettings(job)
+	if err == nil {
+		err = checkJobId(job.JobId)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if s.checkNewJobId(c, job.JobId) == false || s.checkJobQuota(c) == false {
+		return
+	}
 	job.State = model.Stop
 	job.LastExecTime = nil
 	job.ExecType = execType
-	id, err := s.dao.AddJob(job)
+	job.Revision = time.Now().UnixNano()
+	job.ManagedBy = ""
+	id, err := s.daoOf(c).AddJob(job)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err.Error())
 		return
 	}
+	s.audit(namespaceOf(c), actorOf(c), model.AuditCreate, id, nil, s.snapshot(namespaceOf(c), id))
 	c.JSON(http.StatusOK, gin.H{
 		"data": id,
 	})
@@ -126,11 +181,14 @@ func (s *server) Start(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{})
 		return
 	}
-	entity, err := s.dao.GetJobInfo(id)
+	entity, err := s.daoOf(c).GetJobInfo(id)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if s.checkUnmanaged(c, entity) == false {
+		return
+	}
 	enableStr := c.Query("enable")
 	enable, err := strconv.ParseBool(enableStr)
 	if err != nil {
@@ -138,22 +196,35 @@ func (s *server) Start(c *gin.Context) {
 		return
 	}
 	var runnable uint8
+	action := model.AuditDisable
 	if enable {
 		err = checkTimeSettings(entity.ExecType, entity)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		err = checkTaskSettings(entity)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		runnable = model.Runnable
+		action = model.AuditEnable
 	} else {
 		runnable = model.Stop
 	}
-	err = s.dao.UpdateJob(id, map[string]any{model.State: runnable})
-	go s.schedule.HandleJobStateChange(id, runnable)
+	ns := namespaceOf(c)
+	before := s.snapshot(ns, id)
+	err = s.daoOf(c).UpdateJob(id, map[string]any{model.State: runnable})
+	if enable {
+		s.schedule.LinkRun(keyOf(c, id), c.Request.Context())
+	}
+	go s.schedule.HandleJobStateChange(keyOf(c, id), runnable)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err.Error())
 		return
 	}
+	s.audit(ns, actorOf(c), action, id, before, s.snapshot(ns, id))
 	c.JSON(http.StatusOK, gin.H{})
 }
 func (s *server) UpdateScript(c *gin.Context) {
@@ -173,27 +244,43 @@ func (s *server) UpdateScript(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{})
 		return
 	}
-	err = s.dao.UpdateJob(id, map[string]any{model.Script: sc})
+	if j, err := s.daoOf(c).GetJobInfo(id); err == nil && s.checkUnmanaged(c, j) == false {
+		return
+	}
+	_, err = s.saveScript(namespaceOf(c), actorOf(c), id, sc)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{})
 		return
 	}
 }
+
+// saveScript save the script as a new revision.
+func (s *server) saveScript(ns string, a actor, id string, sc string) (int64, error) {
+	before := s.snapshot(ns, id)
+	revision := time.Now().UnixNano()
+	err := s.dao.Namespace(ns).UpdateJob(id, map[string]any{model.Script: sc, model.Revision: revision})
+	if err != nil {
+		return 0, err
+	}
+	s.schedule.InvalidateScript(schedule.JobKey(ns, id))
+	s.audit(ns, a, model.AuditScript, id, before, s.snapshot(ns, id))
+	return revision, nil
+}
 func (s *server) EditPage(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
 		c.JSON(http.StatusNotFound, gin.H{"err": "not found"})
 		return
 	}
-	sc, _ := s.dao.GetJobScript(id)
+	sc, _ := s.daoOf(c).GetJobScript(id)
 	c.HTML(http.StatusOK, "edit_script.html", gin.H{
 		"script": sc.Script,
 	})
 }
 
 func (s *server) GetScript(c *gin.Context) {
-	id := c.Param("id")
-	sc, err := s.dao.GetJobScript(id)
+	id := c.Query("id")
+	sc, err := s.daoOf(c).GetJobScript(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
 		return
@@ -203,7 +290,7 @@ func (s *server) GetScript(c *gin.Context) {
 
 func (s *server) GetJobInfo(c *gin.Context) {
 	id := c.Query("id")
-	j, err := s.dao.GetJobInfo(id)
+	j, err := s.daoOf(c).GetJobInfo(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{})
 		return
@@ -211,6 +298,172 @@ func (s *server) GetJobInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": j})
 }
 
+// RunList the runs of the job, ?q= filters the runs by their output and error.
+func (s *server) RunList(c *gin.Context) {
+	id := c.Query("id")
+	var runs []model.RunEntity
+	var err error
+	if q := c.Query("q"); q != "" {
+		runs, err = s.daoOf(c).SearchRuns(id, q)
+	} else {
+		runs, err = s.daoOf(c).GetRuns(id)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": runs})
+}
+
+func (s *server) GetRun(c *gin.Context) {
+	id := c.Param("runId")
+	run, err := s.daoOf(c).GetRun(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": run})
+}
+
+func (s *server) ActiveRuns(c *gin.Context) {
+	runs, err := s.schedule.ActiveRuns()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+	ns := namespaceOf(c)
+	res := make([]model.RunEntity, 0, len(runs))
+	for _, run := range runs {
+		if run.Namespace == ns {
+			res = append(res, run)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": res})
+}
+
+func (s *server) CancelRun(c *gin.Context) {
+	runId := c.Param("runId")
+	if _, err := s.daoOf(c).GetRun(runId); err != nil { // the run of another namespace.
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	err := s.schedule.CancelRun(runId)
+	if errors.Is(err, schedule.ErrRunNotActive) {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// streamCheck how often a stream checks the run is still executed by a live node.
+var streamCheck = 10 * time.Second
+
+// StreamRun tail the console output of a run as server-sent events.
+// the output of a finished run is sent at once, a running one starts with its last lines.
+func (s *server) StreamRun(c *gin.Context) {
+	runId := c.Param("runId")
+	lines, cancel := s.schedule.TailRun(runId) // subscribe before loading the run, so no line is missed.
+	defer cancel()
+	d := s.daoOf(c)
+	run, err := d.GetRun(runId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	if run.State != model.RunRunning {
+		if run.Output != "" {
+			c.SSEvent("output", strings.TrimSuffix(run.Output, "\n"))
+		}
+		c.SSEvent("end", run.State)
+		return
+	}
+	ticker := time.NewTicker(streamCheck)
+	defer ticker.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case line, ok := <-lines:
+			if ok == false {
+				c.SSEvent("end", "")
+				return false
+			}
+			c.SSEvent("output", line)
+			return true
+		case <-ticker.C:
+			if s.runLost(d, runId) {
+				c.SSEvent("end", "lost") // the node executing it is gone, it would never finish.
+				return false
+			}
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// runLost whether the run is recorded as running, but no live node executes it.
+func (s *server) runLost(d dao.Dao, runId string) bool {
+	active, err := s.schedule.ActiveRuns()
+	if err != nil {
+		return false
+	}
+	for _, r := range active {
+		if r.RunId == runId {
+			return false
+		}
+	}
+	// checked after the active runs, a run finishing meanwhile is saved already.
+	run, err := d.GetRun(runId)
+	return err == nil && run.State == model.RunRunning
+}
+
+// Healthz the process is alive.
+func (s *server) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz the node can serve and schedule jobs, 503 with the failed checks if not.
+func (s *server) Readyz(c *gin.Context) {
+	status := http.StatusOK
+	checks := make(map[string]string)
+	for name, err := range s.schedule.Ready() {
+		checks[name] = "ok"
+		if err != nil {
+			checks[name] = err.Error()
+			status = http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(status, gin.H{"checks": checks})
+}
+
+func (s *server) Cluster(c *gin.Context) {
+	info, err := s.schedule.Cluster()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": info})
+}
+
+// Trigger run the job once now, the id of the run is returned.
+func (s *server) Trigger(c *gin.Context) {
+	id := c.Query("id")
+	if _, err := s.daoOf(c).GetJobInfo(id); id == "" || err != nil {
+		c.JSON(http.StatusNotFound, gin.H{})
+		return
+	}
+	s.schedule.LinkRun(keyOf(c, id), c.Request.Context())
+	runId := s.schedule.Trigger(keyOf(c, id))
+	c.JSON(http.StatusOK, gin.H{"data": runId})
+}
+
+func (s *server) PluginList(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": js_module.GetPlugins()})
+}
+
 func (s *server) Run(c *gin.Context) {
 	execType, err := getJobType(c)
 	if err != nil {
@@ -228,20 +481,35 @@ func (s *server) Run(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	err = checkTaskSettings(entity)
+	if err == nil {
+		err = checkJobId(entity.JobId)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if s.checkNewJobId(c, entity.JobId) == false || s.checkJobQuota(c) == false {
+		return
+	}
 
 	entity.LastExecTime = nil
 	entity.State = model.Runnable
 	entity.ExecType = execType
+	entity.Revision = time.Now().UnixNano()
+	entity.ManagedBy = ""
 
-	id, err := s.dao.AddJob(entity)
+	id, err := s.daoOf(c).AddJob(entity)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
+	s.audit(namespaceOf(c), actorOf(c), model.AuditRun, id, nil, s.snapshot(namespaceOf(c), id))
 	// schedule the job.
-	s.schedule.HandleJobStateChange(id, model.Runnable)
+	s.schedule.LinkRun(keyOf(c, id), c.Request.Context())
+	s.schedule.HandleJobStateChange(keyOf(c, id), model.Runnable)
 	c.JSON(http.StatusOK, gin.H{
 		"data": id,
 	})
@@ -265,6 +533,40 @@ func checkTimeSettings(execType uint8, entity model.JobEntity) error {
 	return nil
 }
 
+func checkTaskSettings(entity model.JobEntity) error {
+	switch entity.TaskType {
+	case model.ScriptTask:
+		return nil
+	case model.CommandTask:
+		return schedule.CheckCommand(entity.Command)
+	case model.HttpTask:
+		return schedule.CheckHttp(entity.Http)
+	default:
+		return errors.New("invalid task type")
+	}
+}
+
+// checkJobId a given id must not contain the separator of the schedule keys, nor look like the key of a
+// namespace in the local store, ns_<ns>:<id>, where the jobs of the default namespace are kept by their bare ids.
+func checkJobId(id string) error {
+	if strings.ContainsAny(id, "/:") || strings.HasPrefix(id, "ns_") {
+		return errors.New("invalid job id")
+	}
+	return nil
+}
+
+// checkNewJobId a given id must not be the id of a job of the namespace, adding it would overwrite the job.
+func (s *server) checkNewJobId(c *gin.Context, id string) bool {
+	if id == "" {
+		return true
+	}
+	if _, err := s.daoOf(c).GetJobInfo(id); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "job " + id + " already exists"})
+		return false
+	}
+	return true
+}
+
 func getJobType(c *gin.Context) (uint8, error) {
 	t := c.Query("type")
 	var execType uint8
@@ -279,10 +581,13 @@ func getJobType(c *gin.Context) (uint8, error) {
 }
 
 type wsWriter struct {
+	mu sync.Mutex
 	ws *websocket.Conn
 }
 
 func (w *wsWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	err = w.ws.WriteMessage(websocket.TextMessage, p)
 	if err != nil {
 		return 0, err
@@ -290,8 +595,20 @@ func (w *wsWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
+// outputWriter send the console output as debugger events.
+type outputWriter struct {
+	send func(debugger.Event)
+}
+
+func (w *outputWriter) Write(p []byte) (n int, err error) {
+	w.send(debugger.Event{Event: debugger.EventOutput, Text: string(p)})
+	return len(p), nil
+}
+
 /**********WS***********/
 
+// Debug with ?protocol=json the messages are debugger commands and events,
+// the first message must be the start command. otherwise the console output is streamed as text.
 func (s *server) Debug(c *gin.Context) {
 	id := c.Query("id")
 	ws, err := s.upgrade.Upgrade(c.Writer, c.Request, nil)
@@ -306,10 +623,74 @@ func (s *server) Debug(c *gin.Context) {
 	write := wsWriter{
 		ws: ws,
 	}
+	if c.Query("protocol") == "json" {
+		s.debugSession(namespaceOf(c), actorOf(c), id, ws, &write)
+		return
+	}
+
+	fn, wt := s.schedule.CreateTaskForDebug(keyOf(c, id), nil, &write, nil)
+	go fn()
+	wt.Wait()
+}
+
+func (s *server) debugSession(ns string, a actor, id string, ws *websocket.Conn, write *wsWriter) {
+	send := func(e debugger.Event) {
+		buffer, _ := json.Marshal(e)
+		_, _ = write.Write(buffer)
+	}
+	var start debugger.Command
+	err := ws.ReadJSON(&start)
+	if err != nil || start.Cmd != debugger.CmdStart {
+		send(debugger.Event{Event: debugger.EventError, Error: "the first message must be the start command"})
+		return
+	}
+	dbg := debugger.New(send)
+	dbg.SetBreakpoints(start.Lines)
+	draft := &schedule.Draft{Script: start.Script, Params: start.Params}
+	closed := make(chan struct{})
+	go func() {
+		for {
+			var cmd debugger.Command
+			if ws.ReadJSON(&cmd) != nil {
+				dbg.Close() // client is gone.
+				close(closed)
+				return
+			}
+			if cmd.Cmd == debugger.CmdPromote {
+				s.promoteDraft(ns, a, id, draft, cmd, send)
+				continue
+			}
+			dbg.Handle(cmd)
+		}
+	}()
 
-	fn, wt := s.schedule.CreateTaskForDebug(id, &write)
+	fn, wt := s.schedule.CreateTaskForDebug(schedule.JobKey(ns, id), draft, &outputWriter{send: send}, dbg)
 	go fn()
 	wt.Wait()
+	send(debugger.Event{Event: debugger.EventFinished})
+	<-closed // the draft can still be promoted after the run.
+}
+
+// promoteDraft save the draft of the session, or the script of the command, as a new revision.
+func (s *server) promoteDraft(ns string, a actor, id string, draft *schedule.Draft, cmd debugger.Command, send func(debugger.Event)) {
+	sc := cmd.Script
+	if sc == "" {
+		sc = draft.Script
+	}
+	if sc == "" {
+		send(debugger.Event{Event: debugger.EventError, Error: "no draft to promote"})
+		return
+	}
+	if j, err := s.dao.Namespace(ns).GetJobInfo(id); err == nil && j.ManagedBy != "" {
+		send(debugger.Event{Event: debugger.EventError, Error: errManaged(j).Error()})
+		return
+	}
+	revision, err := s.saveScript(ns, a, id, sc)
+	if err != nil {
+		send(debugger.Event{Event: debugger.EventError, Error: err.Error()})
+		return
+	}
+	send(debugger.Event{Event: debugger.EventPromoted, Revision: revision})
 }
 
 /**********************/
@@ -318,6 +699,8 @@ type server struct {
 	schedule schedule.Schedule
 	dao      dao.Dao
 	upgrade  websocket.Upgrader
+	auth     *auth.Authenticator // nil if the authentication is disabled.
+	syncer   *syncer             // nil if no directory is synced.
 }
 
 var ser server
@@ -327,11 +710,8 @@ func makeServer() *server {
 	ser = server{
 		schedule: s,
 		dao:      d,
-		upgrade: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
-		},
+		upgrade:  websocket.Upgrader{CheckOrigin: checkOrigin},
+		auth:     makeAuth(d),
 	}
 	return &ser
 }
@@ -340,28 +720,37 @@ func makeMultiServer(redisStr string, mongoUrl string, cluster string) *server {
 	ser = server{
 		schedule: s,
 		dao:      d,
-		upgrade: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
-		},
+		upgrade:  websocket.Upgrader{CheckOrigin: checkOrigin},
+		auth:     makeAuth(d),
 	}
 	return &ser
 }
 func StartStandalone(engine *gin.Engine) {
 	ser := makeServer()
+	if config.GetConfig(config.SyncDir) != "" {
+		ser.syncer = makeSyncer(ser)
+	}
 	ser.RegistryRouting(engine)
 	RegistryHtml(engine)
 	ctx := context.Background()
 	ser.schedule.Start(ctx)
+	if ser.syncer != nil {
+		ser.syncer.start(ctx)
+	}
 }
 
 func StartMultiNode(redisStr string, mongoUri string, cluster string, engine *gin.Engine) {
 	ser := makeMultiServer(redisStr, mongoUri, cluster)
+	if config.GetConfig(config.SyncDir) != "" {
+		ser.syncer = makeSyncer(ser)
+	}
 	ser.RegistryRouting(engine)
 	RegistryHtml(engine)
 	ctx := context.Background()
 	ser.schedule.Start(ctx)
+	if ser.syncer != nil {
+		ser.syncer.start(ctx)
+	}
 }
 
 func cronCheck(cron string) error {
@@ -377,19 +766,73 @@ func Close() {
 }
 
 func (s *server) RegistryRouting(engine *gin.Engine) {
-	api := engine.Group("/api")
+	// the client ip of the audit and the logs is the peer, unless it's a proxy trusted to forward it.
+	if err := engine.SetTrustedProxies(config.GetConfigList(config.TrustProxies)); err != nil {
+		panic(err)
+	}
+	api := engine.Group("/api", requestTrace, s.requestLog, requestMetrics, s.authenticate, s.scope)
+	view := api.Group("", s.require(auth.Viewer))
+	{
+		view.GET("/me", s.Me)
+		view.GET("/jobList", s.JobList)
+		view.GET("/jobs", s.Jobs)
+		view.GET("/job", s.GetJobInfo)
+		view.GET("/script", s.GetScript)
+		view.GET("/runs", s.RunList)
+		view.GET("/runs/:runId", s.GetRun)
+		view.GET("/runs/:runId/stream", s.StreamRun)
+		view.GET("/runs/active", s.ActiveRuns)
+		view.GET("/plugins", s.PluginList)
+		view.GET("/cluster", s.Cluster)
+		view.GET("/alerts/rules", s.RuleList)
+		view.GET("/audit", s.AuditList)
+		view.GET("/namespaces", s.NamespaceList)
+		view.GET("/export", s.Export)
+		view.GET("/sync", s.SyncStatus)
+	}
+	operate := api.Group("", s.require(auth.Operator))
+	{
+		operate.POST("/enable", s.Start)
+		operate.POST("/trigger", s.Trigger)
+		operate.POST("/runs/:runId/cancel", s.CancelRun)
+	}
+	edit := api.Group("", s.require(auth.Editor))
+	{
+		edit.DELETE("/job", s.Remove)
+		edit.PUT("/job", s.Update)
+		edit.POST("/job", s.Create)
+		edit.POST("/script", s.UpdateScript)
+		edit.GET("/debug", s.Debug)
+		edit.POST("/run", s.Run)
+		edit.POST("/import", s.Import)
+		edit.POST("/sync", s.Sync)
+		edit.GET("/alerts/channels", s.ChannelList) // the channels hold credentials.
+		edit.POST("/alerts/channels", s.CreateChannel)
+		edit.PUT("/alerts/channels/:channelId", s.UpdateChannel)
+		edit.DELETE("/alerts/channels/:channelId", s.RemoveChannel)
+		edit.POST("/alerts/channels/:channelId/test", s.TestChannel)
+		edit.POST("/alerts/rules", s.CreateRule)
+		edit.PUT("/alerts/rules/:ruleId", s.UpdateRule)
+		edit.DELETE("/alerts/rules/:ruleId", s.RemoveRule)
+	}
+	admin := api.Group("", s.require(auth.Admin))
 	{
-		api.GET("/jobList", s.JobList)
-		api.DELETE("/job", s.Remove)
-		api.PUT("/job", s.Update)
-		api.POST("/job", s.Create)
-		api.POST("/script", s.UpdateScript)
-		api.GET("/script", s.GetScript)
-		api.GET("/debug", s.Debug)
-		api.POST("/enable", s.Start)
-		api.POST("/run", s.Run)
-	}
-	engine.GET("/edit/:id", s.EditPage)
+		admin.GET("/users", s.UserList)
+		admin.POST("/users", s.CreateUser)
+		admin.PUT("/users/:name", s.UpdateUser)
+		admin.DELETE("/users/:name", s.RemoveUser)
+		admin.GET("/users/:name/tokens", s.TokenList)
+		admin.POST("/users/:name/tokens", s.CreateToken)
+		admin.DELETE("/tokens/:tokenId", s.RemoveToken)
+		admin.POST("/namespaces", s.CreateNamespace)
+		admin.PUT("/namespaces/:name", s.UpdateNamespace)
+		admin.DELETE("/namespaces/:name", s.RemoveNamespace)
+	}
+	engine.GET("/api/openapi.json", OpenApi) // the document is public, the routes it describes are not.
+	engine.GET("/edit/:id", s.authenticate, s.scope, s.require(auth.Editor), s.EditPage)
+	engine.GET("/metrics", gin.WrapH(metrics.Handler()))
+	engine.GET("/healthz", s.Healthz)
+	engine.GET("/readyz", s.Readyz)
 }
 
 func RegistryHtml(r *gin.Engine) {
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"traitor/client"
+	"traitor/schedule"
+)
+
+// notReady a schedule whose dao is not reachable.
+type notReady struct {
+	schedule.Schedule
+}
+
+func (notReady) Ready() map[string]error {
+	return map[string]error{"dao": errors.New("down"), "timeWheel": nil}
+}
+
+func TestReadyz(t *testing.T) {
+	s, c := newTestServer(t)
+	if err := c.Ready(context.Background()); err != nil {
+		t.Fatalf("Ready() = %v", err)
+	}
+
+	s.schedule = notReady{s.schedule}
+	var e *client.Error
+	if err := c.Ready(context.Background()); errors.As(err, &e) == false || e.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Ready() of a node not ready = %v, want 503", err)
+	}
+	res, err := http.Get(c.BaseUrl() + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var body struct {
+		Checks map[string]string `json:"checks"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Checks["dao"] != "down" || body.Checks["timeWheel"] != "ok" {
+		t.Errorf("checks = %v", body.Checks)
+	}
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+	"traitor/dao/model"
+)
+
+func TestStreamRun_lost(t *testing.T) {
+	s, c := newTestServer(t)
+	streamCheck = 100 * time.Millisecond
+	defer func() { streamCheck = 10 * time.Second }()
+	// the node executing the run died, its record is left running.
+	now := time.Now()
+	if _, err := s.dao.AddRun(model.RunEntity{RunId: "lost", JobId: "job", StartAt: &now, State: model.RunRunning}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := c.StreamRun(ctx, "lost", func(string) {}); err != nil {
+		t.Fatalf("StreamRun() of a lost run = %v", err)
+	}
+}
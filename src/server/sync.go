@@ -0,0 +1,320 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"traitor/config"
+	"traitor/dao/model"
+	"traitor/gitops"
+	"traitor/logger"
+	"traitor/schedule"
+)
+
+const (
+	defaultSyncInterval = time.Minute
+	syncKey             = "sync" // the node owning the key syncs the directory.
+)
+
+// the actions of a sync.
+const (
+	syncCreate    = "create"
+	syncUpdate    = "update"
+	syncUnchanged = "unchanged"
+	syncRelease   = "release" // the manifest is gone, the job is disabled and no longer managed.
+	syncInvalid   = "invalid"
+)
+
+// syncActor the actor of the changes made by the sync in the audit log.
+var syncActor = actor{name: "sync"}
+
+// syncItem what the sync did with a manifest, or with a managed job whose manifest is gone.
+type syncItem struct {
+	File      string         `json:"file,omitempty"`
+	Namespace string         `json:"namespace,omitempty"`
+	JobId     string         `json:"jobId,omitempty"`
+	Action    string         `json:"action"`
+	Drift     bool           `json:"drift,omitempty"` // the job was changed after the last sync, not by its manifest.
+	Changes   []model.Change `json:"changes,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// syncResult the outcome of a sync.
+type syncResult struct {
+	Dir        string     `json:"dir"`
+	Revision   string     `json:"revision,omitempty"` // the commit synced from a git checkout.
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt time.Time  `json:"finishedAt"`
+	Error      string     `json:"error,omitempty"` // the directory could not be read, no job is changed.
+	Items      []syncItem `json:"items"`
+}
+
+// syncer keep the jobs of the manifests in the directory in line with them.
+type syncer struct {
+	s        *server
+	dir      string
+	pull     bool
+	interval time.Duration
+
+	mu      sync.Mutex
+	last    *syncResult
+	applied map[string]map[string]any // the fields of the manifests synced last, by job key.
+}
+
+func makeSyncer(s *server) *syncer {
+	interval, err := time.ParseDuration(config.GetConfig(config.SyncInterval))
+	if err != nil || interval <= 0 {
+		interval = defaultSyncInterval
+	}
+	return &syncer{
+		s:        s,
+		dir:      config.GetConfig(config.SyncDir),
+		pull:     config.GetConfig(config.SyncPull) == "true",
+		interval: interval,
+		applied:  make(map[string]map[string]any),
+	}
+}
+
+// start syncing every interval until ctx is done, only the node owning the sync key does it.
+func (y *syncer) start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(y.interval)
+		defer ticker.Stop()
+		for {
+			if y.s.schedule.Owns(syncKey) {
+				y.sync(ctx)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sync the directory once.
+func (y *syncer) sync(ctx context.Context) (res syncResult) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+	res = syncResult{Dir: y.dir, StartedAt: time.Now(), Items: make([]syncItem, 0)}
+	defer func() {
+		res.FinishedAt = time.Now()
+		last := res
+		y.last = &last
+		if res.Error != "" {
+			logger.Error("sync error:", res.Error)
+		}
+	}()
+	if y.pull {
+		if err := gitops.Pull(ctx, y.dir); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+	}
+	res.Revision = gitops.Revision(ctx, y.dir)
+	manifests, problems, err := gitops.Read(y.dir)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	broken := make(map[string]bool) // the jobs of these files are kept as they are.
+	for _, p := range problems {
+		broken[p.File] = true
+		res.Items = append(res.Items, syncItem{File: p.File, Action: syncInvalid, Error: p.Err.Error()})
+	}
+	managed, err := y.managedJobs()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	seen := make(map[string]string) // the files of the job keys.
+	for _, m := range manifests {
+		item := y.apply(m, seen)
+		if item.Action == syncInvalid {
+			broken[m.File] = true
+		}
+		res.Items = append(res.Items, item)
+	}
+	for key, j := range managed {
+		if _, ok := seen[key]; ok || broken[j.ManagedBy] {
+			continue
+		}
+		res.Items = append(res.Items, y.release(j))
+	}
+	sort.SliceStable(res.Items, func(i, k int) bool {
+		return res.Items[i].File < res.Items[k].File
+	})
+	return res
+}
+
+// managedJobs the jobs synced from a manifest, by key.
+func (y *syncer) managedJobs() (map[string]model.JobEntity, error) {
+	namespaces, err := y.s.dao.GetNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]model.JobEntity)
+	for _, n := range namespaces {
+		jobs, err := y.s.dao.Namespace(n.Name).GetJobInfos()
+		if err != nil {
+			return nil, err
+		}
+		for _, j := range jobs {
+			if j.ManagedBy != "" {
+				j.Namespace = n.Name
+				res[schedule.JobKey(n.Name, j.JobId)] = j
+			}
+		}
+	}
+	return res, nil
+}
+
+// apply the manifest to its job.
+func (y *syncer) apply(m gitops.Manifest, seen map[string]string) syncItem {
+	ns := m.Namespace
+	if ns == "" {
+		ns = model.DefaultNamespace
+	}
+	item := syncItem{File: m.File, Namespace: ns, JobId: m.Id, Action: syncInvalid}
+	key := schedule.JobKey(ns, m.Id)
+	if file, ok := seen[key]; ok {
+		item.Error = "the job is defined by " + file + " already"
+		return item
+	}
+	seen[key] = m.File
+	e, err := m.Entity()
+	if err == nil {
+		err = checkJob(e)
+	}
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	e.ManagedBy = m.File
+	n, err := y.s.dao.GetNamespace(ns)
+	if err != nil {
+		item.Error = "namespace " + ns + " is not exists"
+		return item
+	}
+	d := y.s.dao.Namespace(ns)
+	prev, err := d.GetJobInfo(m.Id)
+	exists := err == nil
+	if exists && prev.ManagedBy == "" {
+		item.Error = "a job not managed by the manifests has the id already"
+		return item
+	}
+	after := fields(e)
+	if exists == false {
+		jobs, err := d.GetJobInfos()
+		if err != nil {
+			item.Error = err.Error()
+			return item
+		}
+		if n.MaxJobs > 0 && len(jobs)+1 > n.MaxJobs {
+			item.Error = schedule.ErrQuotaExceeded.Error()
+			return item
+		}
+		item.Action = syncCreate
+	} else if item.Changes = diff(y.s.snapshot(ns, m.Id), after); len(item.Changes) == 0 {
+		item.Action = syncUnchanged
+		y.applied[key] = after
+		return item
+	} else {
+		item.Action = syncUpdate
+		// the manifest is the same as it was synced last, so the job was changed by someone else.
+		if last, ok := y.applied[key]; ok && len(diff(last, after)) == 0 {
+			item.Drift = true
+		}
+	}
+	if _, err = y.s.saveJob(ns, syncActor, model.AuditSync, exists, e); err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	y.applied[key] = after
+	return item
+}
+
+// release disable the job whose manifest is gone and leave it to the API.
+func (y *syncer) release(j model.JobEntity) syncItem {
+	item := syncItem{File: j.ManagedBy, Namespace: j.Namespace, JobId: j.JobId, Action: syncRelease}
+	key := schedule.JobKey(j.Namespace, j.JobId)
+	before := y.s.snapshot(j.Namespace, j.JobId)
+	err := y.s.dao.Namespace(j.Namespace).UpdateJob(j.JobId, map[string]any{model.State: uint8(model.Stop), model.ManagedBy: ""})
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	y.s.schedule.HandleJobStateChange(key, model.Stop)
+	after := y.s.snapshot(j.Namespace, j.JobId)
+	item.Changes = diff(before, after)
+	y.s.audit(j.Namespace, syncActor, model.AuditSync, j.JobId, before, after)
+	delete(y.applied, key)
+	return item
+}
+
+// errManaged the error of changing a managed job through the API.
+func errManaged(j model.JobEntity) error {
+	return errors.New("the job is managed by " + j.ManagedBy + ", change the manifest instead")
+}
+
+// checkUnmanaged whether the job could be changed through the API, it answers 409 if not.
+func (s *server) checkUnmanaged(c *gin.Context, j model.JobEntity) bool {
+	if j.ManagedBy != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": errManaged(j).Error()})
+		return false
+	}
+	return true
+}
+
+// SyncStatus the result of the last sync, null if there was none yet.
+func (s *server) SyncStatus(c *gin.Context) {
+	if s.syncer == nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": "the sync is not configured"})
+		return
+	}
+	s.syncer.mu.Lock()
+	last := s.syncer.last
+	s.syncer.mu.Unlock()
+	if last != nil {
+		last = s.visibleSync(c, *last)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": last, "interval": s.syncer.interval.String(), "owner": s.schedule.Owns(syncKey)})
+}
+
+// visibleSync the result with the items of the namespaces the caller could access only, the changes hold the scripts.
+func (s *server) visibleSync(c *gin.Context, res syncResult) *syncResult {
+	if s.auth == nil {
+		return &res
+	}
+	items := make([]syncItem, 0, len(res.Items))
+	for _, item := range res.Items {
+		if principalOf(c).CanAccess(item.Namespace) {
+			items = append(items, item)
+		}
+	}
+	res.Items = items
+	return &res
+}
+
+// Sync the directory now and answer its result.
+func (s *server) Sync(c *gin.Context) {
+	if s.syncer == nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": "the sync is not configured"})
+		return
+	}
+	if s.schedule.Owns(syncKey) == false {
+		c.JSON(http.StatusConflict, gin.H{"error": "another node syncs the directory"})
+		return
+	}
+	res := s.visibleSync(c, s.syncer.sync(c.Request.Context()))
+	if res.Error != "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"data": res, "err": res.Error})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": res})
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"traitor/auth"
+	"traitor/client"
+	"traitor/dao/model"
+)
+
+func TestSyncer(t *testing.T) {
+	s, c := newTestServer(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "echo.job.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(manifest, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cron: 0 0 0 1 1 * 2099\nenabled: true\ntask: COMMAND\ncommand:\n  args: [echo, hi]\n")
+	y := &syncer{s: s, dir: dir, applied: make(map[string]map[string]any)}
+	s.syncer = y
+
+	if res := y.sync(ctx); res.Error != "" || len(res.Items) != 1 || res.Items[0].Action != syncCreate {
+		t.Fatalf("sync() = %+v", res)
+	}
+	if err := c.UpdateJob(ctx, "echo", map[string]any{"cron": "0 0 0 1 1 * 2098"}); err == nil {
+		t.Fatal("UpdateJob() of a managed job succeeded")
+	}
+	if res := y.sync(ctx); res.Items[0].Action != syncUnchanged {
+		t.Fatalf("sync() = %+v", res)
+	}
+	// a user of another namespace does not see the job, nor its changes.
+	if err := c.CreateNamespace(ctx, model.NamespaceEntity{Name: "team-sync"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateUser(ctx, model.UserEntity{Name: "sync-viewer", Role: auth.Viewer, Namespaces: []string{"team-sync"}}); err != nil {
+		t.Fatal(err)
+	}
+	_, secret, err := c.CreateToken(ctx, "sync-viewer", "test", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, err := client.New(c.BaseUrl(), secret).WithNamespace("team-sync").SyncStatus(ctx); err != nil || status.Last == nil || len(status.Last.Items) != 0 {
+		t.Fatalf("SyncStatus() of another namespace = %+v, %v", status.Last, err)
+	}
+	if status, err := c.SyncStatus(ctx); err != nil || len(status.Last.Items) != 1 {
+		t.Fatalf("SyncStatus() = %+v, %v", status.Last, err)
+	}
+
+	// changed behind the API, it's drift.
+	if err := s.dao.UpdateJob("echo", map[string]any{model.State: uint8(model.Stop)}); err != nil {
+		t.Fatal(err)
+	}
+	if res := y.sync(ctx); res.Items[0].Action != syncUpdate || res.Items[0].Drift == false {
+		t.Fatalf("sync() of a drifted job = %+v", res)
+	}
+	write("cron: 0 0 0 1 1 * 2098\nenabled: true\ntask: COMMAND\ncommand:\n  args: [echo, hi]\n")
+	if res := y.sync(ctx); res.Items[0].Action != syncUpdate || res.Items[0].Drift {
+		t.Fatalf("sync() of a changed manifest = %+v", res)
+	}
+
+	if err := os.Remove(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if res := y.sync(ctx); len(res.Items) != 1 || res.Items[0].Action != syncRelease {
+		t.Fatalf("sync() of a removed manifest = %+v", res)
+	}
+	j, err := c.GetJob(ctx, "echo")
+	if err != nil || j.State != model.Stop || j.ManagedBy != "" {
+		t.Fatalf("GetJob() of the released job = %+v, %v", j, err)
+	}
+	if err = c.UpdateJob(ctx, "echo", map[string]any{"cron": "0 0 0 1 1 * 2097"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"traitor/tracing"
+)
+
+// requestTrace continue the trace of the caller, the span is the parent of what the handler traces.
+func requestTrace(c *gin.Context) {
+	ctx := tracing.Extract(c.Request.Context(), c.Request.Header)
+	ctx, span := tracing.Tracer().Start(ctx, c.Request.Method+" "+c.FullPath(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPMethodKey.String(c.Request.Method), semconv.HTTPRouteKey.String(c.FullPath())))
+	defer span.End()
+	if id := c.Query("id"); id != "" {
+		span.SetAttributes(tracing.JobId.String(id))
+	}
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(c.Writer.Status()))
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+	"time"
+	"traitor/client"
+	"traitor/dao/model"
+)
+
+func TestTrigger_link(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	c := testServer(t)
+	ctx := context.Background()
+	id, err := c.CreateJob(ctx, client.Timing, model.JobEntity{Name: "echo", Cron: "0 0 0 1 1 * 2099",
+		TaskType: model.CommandTask, Command: &model.Command{Args: []string{"echo"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.TriggerJob(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	// the run is a trace of its own, linked to the span of the API call.
+	var api, fire sdktrace.ReadOnlySpan
+	for i := 0; i < 50 && fire == nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+		for _, span := range recorder.Ended() {
+			switch span.Name() {
+			case "POST /api/trigger":
+				api = span
+			case "job.fire":
+				fire = span
+			}
+		}
+	}
+	if api == nil || fire == nil {
+		t.Fatalf("spans of the trigger %v, of the run %v", api, fire)
+	}
+	if links := fire.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != api.SpanContext().SpanID() {
+		t.Errorf("the links of the run = %v, want the API span %v", links, api.SpanContext())
+	}
+}
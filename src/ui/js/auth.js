@@ -0,0 +1,11 @@
+// ask for a token when the API requires one, it's kept in a cookie so page loads and websockets carry it too.
+$(document).ajaxError((event, xhr) => {
+    if (xhr.status !== 401) {
+        return
+    }
+    let token = prompt('API token')
+    if (token) {
+        document.cookie = `traitor_token=${encodeURIComponent(token)}; path=/; SameSite=Strict`
+        location.reload()
+    }
+})
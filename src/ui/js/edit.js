@@ -1,6 +1,7 @@
 const ele = document.getElementById("editor");
 const editor = CodeMirror.fromTextArea(ele, {
-    lineNumbers: true, mode: "javascript", theme: "idea", lineWrapping: true, styleActiveLine: true, matchBrackets: true
+    lineNumbers: true, mode: "javascript", theme: "idea", lineWrapping: true, styleActiveLine: true, matchBrackets: true,
+    gutters: ["CodeMirror-linenumbers", "breakpoints"]
 });
 
 editor.setSize('100%', '100%')
@@ -23,6 +24,9 @@ function saveScript() {
 }
 
 const debug_out = []
+const breakpoints = new Set()
+let ws = null
+let pausedLine = null
 
 
 function response() {
@@ -30,15 +34,117 @@ function response() {
     $('#debug-out').html(v)
 }
 
+// toggle a breakpoint by clicking the gutter.
+editor.on("gutterClick", (cm, n) => {
+    let line = n + 1
+    if (breakpoints.has(line)) {
+        breakpoints.delete(line)
+        cm.setGutterMarker(n, "breakpoints", null)
+    } else {
+        breakpoints.add(line)
+        let marker = document.createElement("div")
+        marker.style.color = "#dc3545"
+        marker.innerHTML = "●"
+        cm.setGutterMarker(n, "breakpoints", marker)
+    }
+    send({cmd: "setBreakpoints", lines: [...breakpoints]})
+})
+
+function send(cmd) {
+    if (ws && ws.readyState === WebSocket.OPEN) {
+        ws.send(JSON.stringify(cmd))
+    }
+}
+
+function highlight(line) {
+    if (pausedLine !== null) {
+        editor.removeLineClass(pausedLine - 1, "background", "bg-warning")
+    }
+    pausedLine = line
+    if (line !== null) {
+        editor.addLineClass(line - 1, "background", "bg-warning")
+    }
+}
+
+function showLocals(locals) {
+    let lines = Object.keys(locals || {}).map(k => `${k} = ${locals[k]}`)
+    $('#debug-locals').val(lines.join('\n'))
+}
+
+function handleEvent(e) {
+    switch (e.event) {
+        case "output":
+            debug_out.push(e.text)
+            break
+        case "paused":
+            highlight(e.line)
+            showLocals(e.locals)
+            debug_out.push(`paused at line ${e.line} (${e.reason})`)
+            break
+        case "resumed":
+            highlight(null)
+            showLocals({})
+            break
+        case "evaluated":
+            debug_out.push(e.error ? `! ${e.error}` : `= ${e.result}`)
+            break
+        case "error":
+            debug_out.push(e.error)
+            break
+        case "promoted":
+            debug_out.push(`saved as revision ${e.revision}`)
+            break
+        case "finished":
+            highlight(null)
+            debug_out.push("finished")
+            break
+    }
+    response()
+}
+
 function debugScript() {
     debug_out.length = 0
+    response()
+    if (ws) {
+        ws.close()
+    }
     let host = window.location.host
     let id = getId()
 
-    const ws = new WebSocket(`ws://${host}/api/debug?id=${id}`)
-    ws.addEventListener('message', e => {
-        debug_out.push(e.data)
+    let params = {}
+    try {
+        params = JSON.parse($('#debug-params').val() || "{}")
+    } catch (e) {
+        debug_out.push(`invalid params: ${e.message}`)
         response()
+        return
+    }
+
+    ws = new WebSocket(`ws://${host}/api/debug?id=${id}&protocol=json`)
+    // run the content of the editor, it's not saved until promoted.
+    ws.addEventListener('open', () => {
+        send({cmd: "start", lines: [...breakpoints], script: editor.getValue(), params: params})
+    })
+    ws.addEventListener('message', e => {
+        handleEvent(JSON.parse(e.data))
     })
 }
 
+function debugCommand(cmd) {
+    send({cmd: cmd})
+}
+
+function evaluate() {
+    let expression = $('#debug-expression').val()
+    if (expression) {
+        send({cmd: "evaluate", expression: expression})
+    }
+}
+
+function promoteDraft() {
+    if (ws && ws.readyState === WebSocket.OPEN) {
+        send({cmd: "promote", script: editor.getValue()})
+    } else {
+        saveScript()
+    }
+}